@@ -0,0 +1,54 @@
+// Command create-admin creates the first admin of the queue, or promotes an
+// existing user to admin. The password is read from ADMIN_PASSWORD so it
+// stays out of the shell history:
+//
+//	ADMIN_PASSWORD=... go run ./cmd/create-admin -email admin@example.com -name Admin
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Nezent/go-queue/config"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/Nezent/go-queue/internal/repository"
+	"github.com/Nezent/go-queue/internal/service"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the admin")
+	name := flag.String("name", "Admin", "name of the admin, if the account is created")
+	flag.Parse()
+
+	db, err := config.ConnectDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		log.Fatalf("Failed to start transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Creating users never sends mail here, no dispatcher is needed
+	users := service.NewUserService(repository.NewUserRepository(db), nil)
+	admin, appErr := users.CreateAdmin(context.WithValue(ctx, middleware.TxKey, tx), domain.UserRegisterDTO{
+		Name:     *name,
+		Email:    *email,
+		Password: os.Getenv("ADMIN_PASSWORD"),
+	})
+	if appErr != nil {
+		log.Fatalf("Failed to create admin: %v", appErr)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Fatalf("Failed to create admin: %v", err)
+	}
+
+	log.Printf("✅ %s (%s) is an admin\n", admin.Email, admin.ID)
+}
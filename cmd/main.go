@@ -5,12 +5,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/Nezent/go-queue/cmd/routes"
 	"github.com/Nezent/go-queue/config"
 	"github.com/Nezent/go-queue/internal/bootstrap"
 	"github.com/Nezent/go-queue/internal/middleware"
 	"github.com/Nezent/go-queue/internal/worker"
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	"github.com/hibiken/asynq"
@@ -41,9 +43,9 @@ func main() {
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           30,
 	}))
@@ -51,20 +53,44 @@ func main() {
 	redisOpt := asynq.RedisClientOpt{
 		Addr: os.Getenv("REDIS_ADDR"),
 	}
+	queueConfig := config.LoadQueueConfig()
 
-	dispatcher := bootstrap.InitializeDispatcher(redisOpt)
+	dispatcher := bootstrap.InitializeDispatcher(queueConfig, redisOpt, db)
+	// The API validates jobs against the same registry the workers run them with
+	jobTypes := bootstrap.InitializeJobTypes(db, dispatcher, config.LoadSMTPConfig())
 	hub := bootstrap.SetupWebSocketHub()
 
+	rateLimits := config.LoadRateLimits(jobTypes)
+	if settings := queueConfig.SchedulerOnlySettings(rateLimits); queueConfig.Broker == config.BrokerPostgres && len(settings) > 0 {
+		// Ignoring them would silently leave jobs unthrottled and unfair
+		log.Fatalf("%s only apply with QUEUE_BROKER=%s", strings.Join(settings, ", "), config.BrokerRedis)
+	}
+	// Only the leader's scheduler consumes its buckets, it saves them for the other replicas to report
+	rateLimiter := ratelimit.NewLimiter(rateLimits)
+
 	// Dependency injection
-	container := bootstrap.Initialize(db, dispatcher, hub)
+	container := bootstrap.Initialize(db, dispatcher, jobTypes, hub, rateLimiter, queueConfig)
 
 	// Initialize the WebSocket Hub
 	go hub.Run()
 
-	worker.InitJobQueue(ctx, dispatcher, container, db)
+	// Every replica relays job notifications to its own WebSocket clients, whichever the broker
+	notifications := worker.NewJobNotifications(db, container)
+	go notifications.Run(ctx)
+
+	// With the Postgres broker the workers claim jobs from the table themselves
+	if queueConfig.Broker == config.BrokerRedis {
+		scheduler := worker.NewJobQueue(container, db, jobTypes.Policies(config.LoadRetryPolicies()), queueConfig)
+
+		// Only the elected replica fills and drains the heap, so jobs aren't dispatched twice
+		elector := worker.NewLeaderElector(db, queueConfig.LeaderLockID, queueConfig.LeaderPollInterval)
+		go elector.Run(ctx, func(leaderCtx context.Context) {
+			worker.LeadJobQueue(leaderCtx, scheduler, notifications, container)
+		})
+	}
 
-	// Initialize the Listeners
-	go worker.StartPgListener(ctx, "job_updates", db, container)
+	// Every replica fires due schedules, row locks keep an occurrence from firing twice
+	go worker.NewScheduleRunner(db, &container.ScheduleHandler, queueConfig).Run(ctx)
 
 	// Register all routes
 	routes.RegisterRoutes(r, container)
@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/Nezent/go-queue/internal/bootstrap"
-	"github.com/Nezent/go-queue/internal/handler"
 	"github.com/Nezent/go-queue/internal/middleware"
 	"github.com/Nezent/go-queue/internal/websocket"
 	"github.com/go-chi/chi/v5"
@@ -17,10 +16,9 @@ func RegisterRoutes(r chi.Router, c *bootstrap.Container) {
 		api.Route("/auth", func(auth chi.Router) {
 			auth.Post("/login", c.UserHandler.LoginHandler)
 			auth.Post("/register", c.UserHandler.RegisterUser)
-			auth.Post("/logout", handler.LogoutHandler)
+			auth.Post("/logout", c.UserHandler.LogoutHandler)
 			auth.Get("/verify", c.UserHandler.VerifyUser) // Verify user email
-			// Optional: Uncomment if you have a refresh token endpoint
-			// auth.Post("/refresh", c.UserHandler.RefreshTokenHandler)
+			auth.Post("/refresh", c.UserHandler.RefreshTokenHandler)
 		})
 
 		// 👤 User Routes (Protected)
@@ -32,6 +30,8 @@ func RegisterRoutes(r chi.Router, c *bootstrap.Container) {
 
 			// users.Get("/", c.UserHandler.GetUsers)
 			// users.Get("/{user_id}", c.UserHandler.GetUserById)
+			users.Get("/webhook-secret", c.UserHandler.GetWebhookSecret)
+			users.Post("/webhook-secret", c.UserHandler.RotateWebhookSecret)
 		})
 
 		// 💼 Job Routes (Protected)
@@ -40,9 +40,64 @@ func RegisterRoutes(r chi.Router, c *bootstrap.Container) {
 
 			// Optional: jobs.Use(middleware.RequireRole("admin", "hr"))
 
-			// jobs.Get("/", c.JobHandler.GetJobs)
+			jobs.Get("/", c.JobHandler.ListJobs)
 			jobs.Post("/", c.JobHandler.CreateJob)
 			jobs.Get("/{job_id}", c.JobHandler.GetJobStatus)
+			jobs.Patch("/{job_id}", c.JobHandler.UpdateJob)
+			jobs.Delete("/{job_id}", c.JobHandler.CancelJob)
+			jobs.Get("/{job_id}/graph", c.JobHandler.GetJobGraph)
+			jobs.Get("/{job_id}/events", c.JobHandler.GetJobEvents)
+
+			// 💀 Dead-letter queue
+			jobs.Route("/dead", func(dead chi.Router) {
+				dead.Get("/", c.DeadJobHandler.ListDeadJobs)
+				dead.Post("/requeue", c.DeadJobHandler.RequeueDeadJobs)
+				dead.Delete("/", c.DeadJobHandler.PurgeDeadJobs)
+				dead.Get("/{job_id}", c.DeadJobHandler.GetDeadJob)
+				dead.Post("/{job_id}/requeue", c.DeadJobHandler.RequeueDeadJob)
+				dead.Delete("/{job_id}", c.DeadJobHandler.PurgeDeadJob)
+			})
+		})
+
+		// 📨 Batch Routes (Protected)
+		api.Route("/batches", func(batches chi.Router) {
+			batches.Use(middleware.AuthMiddleware)
+
+			batches.Post("/", c.BatchHandler.CreateBatch)
+			batches.Get("/{batch_id}", c.BatchHandler.GetBatch)
+		})
+
+		// ⏰ Schedule Routes (Protected)
+		api.Route("/schedules", func(schedules chi.Router) {
+			schedules.Use(middleware.AuthMiddleware)
+
+			schedules.Get("/", c.ScheduleHandler.ListSchedules)
+			schedules.Post("/", c.ScheduleHandler.CreateSchedule)
+			schedules.Get("/{schedule_id}", c.ScheduleHandler.GetSchedule)
+			schedules.Patch("/{schedule_id}", c.ScheduleHandler.UpdateSchedule)
+			schedules.Delete("/{schedule_id}", c.ScheduleHandler.DeleteSchedule)
+		})
+
+		// 🛠️ Admin Routes (Protected)
+		api.Route("/admin", func(admin chi.Router) {
+			admin.Use(middleware.AuthMiddleware)
+			admin.Use(middleware.RequireRole(middleware.RoleOperator, middleware.RoleAdmin))
+
+			// Queue management
+			admin.Group(func(queue chi.Router) {
+				queue.Use(middleware.RequirePermission(middleware.PermissionViewQueue))
+
+				queue.Get("/rate-limits", c.AdminHandler.GetRateLimits)
+				queue.Get("/queue/stats", c.JobHandler.GetQueueStats)
+			})
+
+			// User management
+			admin.Route("/users", func(users chi.Router) {
+				users.Use(middleware.RequirePermission(middleware.PermissionManageUsers))
+
+				users.Get("/", c.UserHandler.ListUsers)
+				users.Patch("/{user_id}/role", c.UserHandler.UpdateUserRole)
+			})
 		})
 
 		// 📦 WebSocket Routes
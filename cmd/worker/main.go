@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/hibiken/asynq"
 	"github.com/joho/godotenv"
 
+	"github.com/Nezent/go-queue/config"
+	"github.com/Nezent/go-queue/internal/bootstrap"
 	"github.com/Nezent/go-queue/internal/worker"
-	"github.com/Nezent/go-queue/internal/worker/processor"
 )
 
 func main() {
@@ -21,22 +25,40 @@ func main() {
 		Addr: os.Getenv("REDIS_ADDR"),
 	}
 
-	config := processor.SMTPConfig{
-		Host:     os.Getenv("SMTP_HOST"),
-		Port:     os.Getenv("SMTP_PORT"),
-		Username: os.Getenv("SMTP_USERNAME"),
-		Password: os.Getenv("SMTP_PASSWORD"),
-		From:     os.Getenv("SMTP_FROM"),
+	queueConfig := config.LoadQueueConfig()
+
+	// Workers record job outcomes in Postgres with either broker
+	db, err := config.ConnectDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	defer db.Close()
+	dispatcher := bootstrap.InitializeDispatcher(queueConfig, redisOpt, db)
+	jobTypes := bootstrap.InitializeJobTypes(db, dispatcher, config.LoadSMTPConfig())
+	retryPolicies := jobTypes.Policies(config.LoadRetryPolicies())
+	jobHandler := bootstrap.InitializeJobHandler(db, dispatcher, jobTypes, queueConfig)
+
+	mux := worker.NewServeMux(jobTypes)
 
-	taskProcessor := processor.NewTaskProcessor(config)
+	if queueConfig.Broker == config.BrokerPostgres {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		broker := worker.NewPgBroker(jobHandler, jobTypes, mux, queueConfig, retryPolicies)
+
+		log.Println("Starting Postgres broker worker...")
+		broker.Run(ctx)
+		log.Println("Postgres broker worker stopped")
+		return
+	}
+
+	tracker := worker.NewJobTracker(jobHandler, jobTypes, worker.NewJobStatusStore(db, jobHandler), worker.NewJobResultStore(db, jobHandler), asynq.NewInspector(redisOpt), retryPolicies, queueConfig.ProgressInterval)
+	mux.Use(tracker.Middleware)
 
 	srv := asynq.NewServer(redisOpt, asynq.Config{
-		Concurrency: 10,
+		Concurrency: queueConfig.Concurrency,
 	})
 
-	mux := worker.NewServeMux(taskProcessor)
-
 	log.Println("Starting Asynq worker...")
 	if err := srv.Run(mux); err != nil {
 		log.Fatalf("could not run worker: %v", err)
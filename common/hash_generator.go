@@ -1,7 +1,9 @@
 package common
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 
@@ -30,3 +32,12 @@ func GenerateHashPassword(password string) (*string, error) {
 func CompareHashPassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
+
+// GenerateRefreshToken returns an opaque random token; only its GenerateHash is stored.
+func GenerateRefreshToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
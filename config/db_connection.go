@@ -73,3 +73,12 @@ func getEnvAsInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvAsDuration parses an environment variable as time.Duration or returns fallback
+func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	valStr := os.Getenv(key)
+	if val, err := time.ParseDuration(valStr); err == nil {
+		return val
+	}
+	return fallback
+}
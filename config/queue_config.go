@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
+	"github.com/google/uuid"
+)
+
+const (
+	// BrokerRedis schedules jobs in the API's in-memory heap and runs them through asynq.
+	BrokerRedis = "redis"
+	// BrokerPostgres lets workers claim due jobs straight from the jobs table.
+	BrokerPostgres = "postgres"
+
+	// FairnessFIFO dispatches due jobs by run time and priority alone.
+	FairnessFIFO = "fifo"
+	// FairnessDRR shares dispatch between users by deficit round-robin.
+	FairnessDRR = "drr"
+)
+
+// QueueConfig selects the job broker and tunes the Postgres broker.
+type QueueConfig struct {
+	Broker       string
+	Concurrency  int
+	PollInterval time.Duration
+	// LeaseDuration is how long a claimed job stays with its worker without
+	// being renewed; workers renew it every third of the duration
+	LeaseDuration time.Duration
+	// LeaderLockID is the advisory lock key API replicas compete for
+	LeaderLockID int64
+	// LeaderPollInterval bounds how long followers take to replace a dead leader
+	LeaderPollInterval time.Duration
+	// SchedulePollInterval is how often due cron schedules are looked for
+	SchedulePollInterval time.Duration
+	// ScheduleMisfireGrace is how late a "skip" schedule may still fire an occurrence
+	ScheduleMisfireGrace time.Duration
+	// Fairness decides how due jobs of different users are interleaved
+	Fairness string
+	// UserWeights gives users a larger share of dispatch under FairnessDRR
+	UserWeights map[uuid.UUID]int
+	// PriorityAgingInterval raises the priority of a due job by one level for
+	// every interval it waits to be dispatched; zero disables aging
+	PriorityAgingInterval time.Duration
+	// ProgressInterval throttles how often the progress of a job is saved and broadcast
+	ProgressInterval time.Duration
+}
+
+// LoadQueueConfig reads the queue configuration from the environment.
+func LoadQueueConfig() QueueConfig {
+	cfg := QueueConfig{
+		Broker:        getEnv("QUEUE_BROKER", BrokerRedis),
+		Concurrency:   getEnvAsInt("QUEUE_CONCURRENCY", 10),
+		PollInterval:  getEnvAsDuration("QUEUE_POLL_INTERVAL", time.Second),
+		LeaseDuration: getEnvAsDuration("QUEUE_LEASE_DURATION", 5*time.Minute),
+		// Arbitrary key, only has to be shared by every replica of the API
+		LeaderLockID:       int64(getEnvAsInt("LEADER_LOCK_ID", 724_531)),
+		LeaderPollInterval: getEnvAsDuration("LEADER_POLL_INTERVAL", 5*time.Second),
+
+		SchedulePollInterval: getEnvAsDuration("SCHEDULE_POLL_INTERVAL", time.Second),
+		ScheduleMisfireGrace: getEnvAsDuration("SCHEDULE_MISFIRE_GRACE", time.Minute),
+
+		Fairness:    getEnv("SCHEDULER_FAIRNESS", FairnessFIFO),
+		UserWeights: loadUserWeights(),
+
+		PriorityAgingInterval: getEnvAsDuration("PRIORITY_AGING_INTERVAL", 0),
+		ProgressInterval:      getEnvAsDuration("PROGRESS_INTERVAL", time.Second),
+	}
+
+	if cfg.Broker != BrokerRedis && cfg.Broker != BrokerPostgres {
+		log.Printf("[WARN] Unknown QUEUE_BROKER %q, falling back to %q", cfg.Broker, BrokerRedis)
+		cfg.Broker = BrokerRedis
+	}
+	if cfg.Fairness != FairnessFIFO && cfg.Fairness != FairnessDRR {
+		log.Printf("[WARN] Unknown SCHEDULER_FAIRNESS %q, falling back to %q", cfg.Fairness, FairnessFIFO)
+		cfg.Fairness = FairnessFIFO
+	}
+	if cfg.LeaseDuration <= 0 {
+		log.Printf("[WARN] Invalid QUEUE_LEASE_DURATION %s, falling back to %s", cfg.LeaseDuration, 5*time.Minute)
+		cfg.LeaseDuration = 5 * time.Minute
+	}
+	return cfg
+}
+
+// SchedulerOnlySettings names the settings in effect that only the scheduler
+// of the Redis broker applies. Workers of the Postgres broker claim due jobs
+// by priority and run time alone.
+func (cfg QueueConfig) SchedulerOnlySettings(limits ratelimit.Config) []string {
+	var settings []string
+	if !limits.Empty() {
+		settings = append(settings, "RATE_LIMITS")
+	}
+	if cfg.Fairness != FairnessFIFO {
+		settings = append(settings, "SCHEDULER_FAIRNESS")
+	}
+	if len(cfg.UserWeights) > 0 {
+		settings = append(settings, "SCHEDULER_USER_WEIGHTS")
+	}
+	if cfg.PriorityAgingInterval > 0 {
+		settings = append(settings, "PRIORITY_AGING_INTERVAL")
+	}
+	return settings
+}
+
+// loadUserWeights reads SCHEDULER_USER_WEIGHTS, a JSON object of user IDs to
+// their weight, e.g. {"6f1c...": 3}. Users without a weight get 1.
+func loadUserWeights() map[uuid.UUID]int {
+	weights := map[uuid.UUID]int{}
+
+	raw := os.Getenv("SCHEDULER_USER_WEIGHTS")
+	if raw == "" {
+		return weights
+	}
+
+	if err := json.Unmarshal([]byte(raw), &weights); err != nil {
+		log.Printf("[WARN] Ignoring invalid SCHEDULER_USER_WEIGHTS: %v", err)
+		return map[uuid.UUID]int{}
+	}
+	for userID, weight := range weights {
+		if weight < 1 {
+			log.Printf("[WARN] Ignoring weight of user %s, it must be at least 1", userID)
+			delete(weights, userID)
+		}
+	}
+	return weights
+}
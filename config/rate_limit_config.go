@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
+)
+
+// LoadRateLimits reads dispatch rate limits from RATE_LIMITS, e.g.
+//
+//	{"types": {"email": {"rate": 20, "burst": 50}}, "default_user": {"rate": 2, "burst": 10}}
+//
+// Rates are jobs per second. "types" is keyed by the job types of types, the
+// type jobs are created with; "users" sets limits of specific user IDs.
+func LoadRateLimits(types *jobtype.Registry) ratelimit.Config {
+	limits := ratelimit.Config{}
+
+	raw := os.Getenv("RATE_LIMITS")
+	if raw == "" {
+		return limits
+	}
+
+	if err := json.Unmarshal([]byte(raw), &limits); err != nil {
+		log.Printf("[WARN] Ignoring invalid RATE_LIMITS: %v", err)
+		return ratelimit.Config{}
+	}
+	for jobType, limit := range limits.Types {
+		// A typo or a task name would silently leave the type unlimited
+		if _, ok := types.Lookup(jobType); !ok {
+			log.Printf("[WARN] Ignoring rate limit of unknown job type %q", jobType)
+			delete(limits.Types, jobType)
+			continue
+		}
+		if !limit.Valid() {
+			log.Printf("[WARN] Ignoring rate limit of %q, rate and burst must be positive", jobType)
+			delete(limits.Types, jobType)
+		}
+	}
+	for userID, limit := range limits.Users {
+		if !limit.Valid() {
+			log.Printf("[WARN] Ignoring rate limit of user %s, rate and burst must be positive", userID)
+			delete(limits.Users, userID)
+		}
+	}
+	if limits.DefaultUser != nil && !limits.DefaultUser.Valid() {
+		log.Printf("[WARN] Ignoring default user rate limit, rate and burst must be positive")
+		limits.DefaultUser = nil
+	}
+	return limits
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/Nezent/go-queue/internal/worker/retry"
+)
+
+// LoadRetryPolicies reads per job type retry policies from RETRY_POLICIES, a
+// JSON object keyed by job type, e.g.
+//
+//	{"default": {"max_attempts": 3}, "email": {"max_attempts": 5, "base_delay": "30s"}}
+//
+// The "default" entry applies to every type without an entry of its own; an
+// entry overrides the fields of the policy the job type registered.
+func LoadRetryPolicies() retry.Policies {
+	policies := retry.Policies{
+		Default: retry.DefaultPolicy,
+		Types:   map[string]retry.Override{},
+	}
+
+	raw := os.Getenv("RETRY_POLICIES")
+	if raw == "" {
+		return policies
+	}
+
+	var configured map[string]retry.Override
+	if err := json.Unmarshal([]byte(raw), &configured); err != nil {
+		log.Printf("[WARN] Ignoring invalid RETRY_POLICIES: %v", err)
+		return policies
+	}
+	for jobType, policy := range configured {
+		if jobType == "default" {
+			policies.Default = policies.Default.Merge(&policy)
+			continue
+		}
+		policies.Types[jobType] = policy
+	}
+	return policies
+}
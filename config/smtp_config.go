@@ -0,0 +1,18 @@
+package config
+
+import (
+	"os"
+
+	"github.com/Nezent/go-queue/internal/worker/processor"
+)
+
+// LoadSMTPConfig reads the mail server the email job types send through.
+func LoadSMTPConfig() processor.SMTPConfig {
+	return processor.SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
@@ -1,41 +1,87 @@
 package bootstrap
 
 import (
+	"github.com/Nezent/go-queue/config"
 	"github.com/Nezent/go-queue/internal/handler"
 	"github.com/Nezent/go-queue/internal/repository"
 	"github.com/Nezent/go-queue/internal/service"
 	"github.com/Nezent/go-queue/internal/websocket"
 	"github.com/Nezent/go-queue/internal/worker/enqueue"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/processor"
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Container struct {
-	UserHandler    handler.UserHandler
-	JobHandler     handler.JobHandler
-	TaskDispatcher *enqueue.TaskDispatcher
-	WebSocketHub   *websocket.Hub
+	UserHandler     handler.UserHandler
+	JobHandler      handler.JobHandler
+	DeadJobHandler  handler.DeadJobHandler
+	ScheduleHandler handler.JobScheduleHandler
+	BatchHandler    handler.JobBatchHandler
+	AdminHandler    handler.AdminHandler
+	TaskDispatcher  enqueue.Dispatcher
+	JobTypes        *jobtype.Registry
+	WebSocketHub    *websocket.Hub
+	RateLimiter     *ratelimit.Limiter
 }
 
-func Initialize(db *pgxpool.Pool, dispatcher *enqueue.TaskDispatcher, webSocketHub *websocket.Hub) *Container {
+func Initialize(db *pgxpool.Pool, dispatcher enqueue.Dispatcher, jobTypes *jobtype.Registry, webSocketHub *websocket.Hub, rateLimiter *ratelimit.Limiter, queueConfig config.QueueConfig) *Container {
 
 	return &Container{
 		UserHandler: handler.UserHandler{
 			Service: service.NewUserService(repository.NewUserRepository(db), dispatcher),
 		},
 		TaskDispatcher: dispatcher,
-		JobHandler: handler.JobHandler{
-			Service: service.NewJobService(repository.NewJobRepository(db)),
+		JobTypes:       jobTypes,
+		JobHandler:     *InitializeJobHandler(db, dispatcher, jobTypes, queueConfig),
+		DeadJobHandler: handler.DeadJobHandler{
+			Service: service.NewDeadJobService(repository.NewDeadJobRepository(db)),
+		},
+		ScheduleHandler: handler.JobScheduleHandler{
+			Service: service.NewJobScheduleService(repository.NewJobScheduleRepository(db), jobTypes),
+		},
+		BatchHandler: handler.JobBatchHandler{
+			Service: service.NewJobBatchService(repository.NewJobRepository(db), repository.NewJobBatchRepository(db), jobTypes),
+		},
+		AdminHandler: handler.AdminHandler{
+			RateLimitService: service.NewRateLimitService(repository.NewRateLimitRepository(db)),
 		},
 		WebSocketHub: webSocketHub,
+		RateLimiter:  rateLimiter,
 		// other handlers...
 	}
 }
 
-func InitializeDispatcher(redisOpt asynq.RedisClientOpt) *enqueue.TaskDispatcher {
+// InitializeDispatcher picks the task dispatcher matching the configured broker.
+func InitializeDispatcher(cfg config.QueueConfig, redisOpt asynq.RedisClientOpt, db *pgxpool.Pool) enqueue.Dispatcher {
+	if cfg.Broker == config.BrokerPostgres {
+		return enqueue.NewPgTaskDispatcher(db)
+	}
 	return enqueue.NewTaskDispatcher(redisOpt)
 }
 
+// InitializeJobTypes registers every job type the processors run.
+func InitializeJobTypes(db *pgxpool.Pool, dispatcher enqueue.Dispatcher, smtpConfig processor.SMTPConfig) *jobtype.Registry {
+	users := &handler.UserHandler{
+		Service: service.NewUserService(repository.NewUserRepository(db), dispatcher),
+	}
+
+	jobTypes := jobtype.NewRegistry()
+	processor.NewTaskProcessor(smtpConfig).Register(jobTypes)
+	// Requests time out on their own, see task.HTTPRequestPayload
+	processor.NewHTTPProcessor(processor.NewPublicClient(), users).Register(jobTypes)
+	return jobTypes
+}
+
+// InitializeJobHandler wires the job handler for processes that only need job persistence.
+func InitializeJobHandler(db *pgxpool.Pool, dispatcher enqueue.Dispatcher, jobTypes *jobtype.Registry, queueConfig config.QueueConfig) *handler.JobHandler {
+	return &handler.JobHandler{
+		Service: service.NewJobService(repository.NewJobRepository(db), dispatcher, jobTypes, queueConfig.PriorityAgingInterval),
+	}
+}
+
 func SetupWebSocketHub() *websocket.Hub {
 	hub := websocket.NewHub()
 	return hub
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobAttempt is one failed attempt in a job's history.
+type JobAttempt struct {
+	Attempt  int       `json:"attempt"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadJob is a job that ran out of attempts, kept with why it failed.
+type DeadJob struct {
+	JobID          uuid.UUID      `json:"job_id"`
+	UserID         uuid.UUID      `json:"user_id"`
+	Type           string         `json:"type"`
+	Payload        map[string]any `json:"payload"`
+	Attempts       int            `json:"attempts"`
+	LastError      string         `json:"last_error"`
+	AttemptHistory []JobAttempt   `json:"attempt_history"`
+	FailedAt       time.Time      `json:"failed_at"`
+}
+
+type DeadJobIDsRequestDTO struct {
+	JobIDs []uuid.UUID `json:"job_ids"`
+}
+
+type DeadJobsAffectedResponseDTO struct {
+	JobIDs []uuid.UUID `json:"job_ids"`
+	Count  int         `json:"count"`
+}
@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/Nezent/go-queue/internal/worker/retry"
 	"github.com/google/uuid"
 )
 
@@ -17,19 +19,133 @@ type Job struct {
 	RunAt     time.Time      `json:"run_at"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
+	// RetryPolicy overrides the retry policy of the job type, if set
+	RetryPolicy *retry.Override `json:"retry_policy,omitempty"`
+	// DependsOn lists the jobs that must complete before this one runs
+	DependsOn []JobDependency `json:"depends_on,omitempty"`
+	// BatchID is the batch the job was created in, if any
+	BatchID *uuid.UUID `json:"batch_id,omitempty"`
+	// IdempotencyKey is the Idempotency-Key header of the creating request
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// UniqueKey deduplicates jobs for UniqueWindow after creation, forever if zero
+	UniqueKey    string         `json:"unique_key,omitempty"`
+	UniqueWindow retry.Duration `json:"unique_window,omitempty"`
+	// Result is what the job's handler produced, LastError why its latest attempt failed
+	Result    json.RawMessage `json:"result,omitempty"`
+	LastError string          `json:"last_error,omitempty"`
+	// StartedAt and FinishedAt time the latest attempt
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Replayed is set when an existing job was returned instead of creating one
+	Replayed bool `json:"-"`
 }
 
 type JobCreateRequestDTO struct {
-	Type     string         `json:"type"`
-	Payload  map[string]any `json:"payload"`
-	Priority string         `json:"priority"`
-	RunAt    string         `json:"run_at"`
+	Type     string          `json:"type"`
+	Payload  map[string]any  `json:"payload"`
+	Priority string          `json:"priority"`
+	RunAt    string          `json:"run_at"`
+	Retry    *retry.Override `json:"retry,omitempty"`
+	// DependsOn keeps the job blocked until these jobs completed
+	DependsOn []JobDependency `json:"depends_on,omitempty"`
+	// UniqueKey makes the creation a no-op while a job with the same key exists
+	// that was created less than UniqueWindow ago (ever, if zero)
+	UniqueKey    string         `json:"unique_key,omitempty"`
+	UniqueWindow retry.Duration `json:"unique_window,omitempty"`
+	// IdempotencyKey is read from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
+}
+
+// JobUpdateRequestDTO changes a job that has not started; omitted fields are kept.
+type JobUpdateRequestDTO struct {
+	Payload  map[string]any `json:"payload,omitempty"`
+	Priority *string        `json:"priority,omitempty"`
+	RunAt    *string        `json:"run_at,omitempty"`
 }
 
 type JobStatusResponseDTO struct {
-	Type     string    `json:"type"`
-	Status   string    `json:"status"`
-	Priority string    `json:"priority"`
-	Attempts int       `json:"attempts"`
-	RunAt    time.Time `json:"run_at"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+	// EffectivePriority is Priority raised by the time the job has been due
+	EffectivePriority string    `json:"effective_priority"`
+	Attempts          int       `json:"attempts"`
+	RunAt             time.Time `json:"run_at"`
+
+	// Progress is the latest progress the running job reported
+	Progress        int    `json:"progress"`
+	ProgressMessage string `json:"progress_message,omitempty"`
+
+	Result     json.RawMessage `json:"result"`
+	LastError  string          `json:"last_error"`
+	StartedAt  *time.Time      `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+}
+
+// JobStatuses lists every status a job can be in.
+var JobStatuses = []string{"pending", "blocked", "queued", "processing", "retrying", "completed", "failed", "cancelled", "skipped"}
+
+// JobListRequestDTO holds the query parameters of a job listing as sent.
+type JobListRequestDTO struct {
+	Statuses   []string
+	Types      []string
+	Priorities []string
+	// RunAtFrom, RunAtTo, CreatedFrom and CreatedTo are RFC 3339 bounds, inclusive
+	RunAtFrom   string
+	RunAtTo     string
+	CreatedFrom string
+	CreatedTo   string
+	// Payload maps dotted payload paths, e.g. "recipient", to the value they must have
+	Payload map[string]string
+	// Sort is created_at or run_at, prefixed with "-" for descending order
+	Sort   string
+	Cursor string
+	Limit  int
+	// Count asks for the number of jobs matching the filters
+	Count bool
+}
+
+// JobFilter selects a page of a user's jobs.
+type JobFilter struct {
+	Statuses    []string
+	Types       []string
+	Priorities  []string
+	RunAtFrom   *time.Time
+	RunAtTo     *time.Time
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Payload     []PayloadMatch
+	// SortBy is created_at or run_at, ties are broken by ID
+	SortBy     string
+	Descending bool
+	// After continues a listing past the job it points at
+	After *JobCursor
+	Limit int
+}
+
+// PayloadMatch requires the payload field at Path to have the text value Value.
+type PayloadMatch struct {
+	Path  []string
+	Value string
+}
+
+// JobCursor points at the last job of a page.
+type JobCursor struct {
+	Sort  string    `json:"s"`
+	Value time.Time `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+type JobListResponseDTO struct {
+	Jobs []Job `json:"jobs"`
+	// NextCursor fetches the following page, it is empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total counts every job matching the filters if it was asked for
+	Total *int `json:"total,omitempty"`
+}
+
+// QueueStatsResponseDTO counts the jobs of every user by status.
+type QueueStatsResponseDTO struct {
+	Statuses map[string]int `json:"statuses"`
+	Total    int            `json:"total"`
 }
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobBatch groups jobs created together, with aggregate counts of their statuses.
+type JobBatch struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	Total           int        `json:"total"`
+	Pending         int        `json:"pending"`
+	Completed       int        `json:"completed"`
+	Failed          int        `json:"failed"` // failed, cancelled or skipped
+	OnCompleteJobID *uuid.UUID `json:"on_complete_job_id,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type JobBatchCreateRequestDTO struct {
+	Jobs []JobCreateRequestDTO `json:"jobs"`
+	// OnComplete runs once every job of the batch finished, whatever the outcome
+	OnComplete *JobCreateRequestDTO `json:"on_complete,omitempty"`
+}
+
+type JobBatchCreateResponseDTO struct {
+	Batch  JobBatch    `json:"batch"`
+	JobIDs []uuid.UUID `json:"job_ids"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What happens to a blocked job when a job it depends on fails, is cancelled
+// or is skipped.
+const (
+	// DependencyOnFailureFail fails the dependent job as well.
+	DependencyOnFailureFail = "fail"
+	// DependencyOnFailureSkip skips the dependent job.
+	DependencyOnFailureSkip = "skip"
+)
+
+// JobDependency is an edge of a workflow: the job only runs once JobID completed.
+type JobDependency struct {
+	JobID     uuid.UUID `json:"job_id"`
+	OnFailure string    `json:"on_failure"`
+}
+
+// UnmarshalJSON accepts either a bare job ID or an object with its failure policy.
+func (d *JobDependency) UnmarshalJSON(data []byte) error {
+	var jobID uuid.UUID
+	if err := json.Unmarshal(data, &jobID); err == nil {
+		*d = JobDependency{JobID: jobID}
+		return nil
+	}
+
+	type plain JobDependency
+	return json.Unmarshal(data, (*plain)(d))
+}
+
+// JobGraphNode is a job of a workflow with its current status.
+type JobGraphNode struct {
+	ID       uuid.UUID `json:"id"`
+	Type     string    `json:"type"`
+	Status   string    `json:"status"`
+	Priority string    `json:"priority"`
+	RunAt    time.Time `json:"run_at"`
+}
+
+// JobGraphEdge says JobID depends on DependsOn.
+type JobGraphEdge struct {
+	JobID     uuid.UUID `json:"job_id"`
+	DependsOn uuid.UUID `json:"depends_on"`
+	OnFailure string    `json:"on_failure"`
+}
+
+// JobGraphResponseDTO is the whole workflow a job belongs to.
+type JobGraphResponseDTO struct {
+	Nodes []JobGraphNode `json:"nodes"`
+	Edges []JobGraphEdge `json:"edges"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobEvent is one transition of a job's timeline.
+type JobEvent struct {
+	ID    int64     `json:"id"`
+	JobID uuid.UUID `json:"job_id"`
+	// Event is created, dispatched when a worker picks the job up, or the status it moved to
+	Event   string `json:"event"`
+	Status  string `json:"status"`
+	Attempt int    `json:"attempt"`
+	// Actor is "user:<id>", "scheduler", "schedule", "worker[:<id>]" or "system"
+	Actor     string    `json:"actor"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
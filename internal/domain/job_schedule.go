@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobSchedule creates a job of JobType from Payload at every occurrence of
+// its cron spec, evaluated in Timezone.
+type JobSchedule struct {
+	ID        uuid.UUID      `json:"id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	CronSpec  string         `json:"cron_spec"`
+	Timezone  string         `json:"timezone"`
+	JobType   string         `json:"job_type"`
+	Payload   map[string]any `json:"payload"`
+	Priority  string         `json:"priority"`
+	CatchUp   string         `json:"catch_up"`
+	Enabled   bool           `json:"enabled"`
+	NextRunAt time.Time      `json:"next_run_at"`
+	LastRunAt *time.Time     `json:"last_run_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type JobScheduleCreateRequestDTO struct {
+	CronSpec string         `json:"cron_spec"`
+	Timezone string         `json:"timezone"`
+	JobType  string         `json:"job_type"`
+	Payload  map[string]any `json:"payload"`
+	Priority string         `json:"priority"`
+	CatchUp  string         `json:"catch_up"`
+	Enabled  *bool          `json:"enabled,omitempty"`
+}
+
+// JobScheduleUpdateRequestDTO changes a schedule; omitted fields are kept.
+type JobScheduleUpdateRequestDTO struct {
+	CronSpec *string        `json:"cron_spec,omitempty"`
+	Timezone *string        `json:"timezone,omitempty"`
+	JobType  *string        `json:"job_type,omitempty"`
+	Payload  map[string]any `json:"payload,omitempty"`
+	Priority *string        `json:"priority,omitempty"`
+	CatchUp  *string        `json:"catch_up,omitempty"`
+	Enabled  *bool          `json:"enabled,omitempty"`
+}
@@ -14,6 +14,8 @@ type User struct {
 	EmailVerified     bool      `json:"email_verified"`
 	VerificationToken string    `json:"verification_token"`
 	LastLoginAt       time.Time `json:"last_login_at"`
+	// Role decides what the user may do besides managing their own jobs
+	Role string `json:"role"`
 }
 
 type UserRegisterDTO struct {
@@ -38,4 +40,31 @@ type UserLoginRequestDTO struct {
 
 type UserLoginResponseDTO struct {
 	AccessToken string `json:"access_token"`
+	// RefreshToken is exchanged at /auth/refresh for a new pair of tokens
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshTokenRequestDTO carries the refresh token of clients that don't keep cookies.
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// WebhookSecretResponseDTO carries the key the user's http:request jobs are
+// signed with, see processor.Sign.
+type WebhookSecretResponseDTO struct {
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+// UserSummaryDTO is a user as listed to admins.
+type UserSummaryDTO struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+}
+
+type UserRoleUpdateRequestDTO struct {
+	Role string `json:"role"`
 }
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/service"
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
+)
+
+type AdminHandler struct {
+	RateLimitService service.RateLimitService
+}
+
+// GetRateLimits reports the dispatch token buckets of the leader, whichever
+// replica handles the request.
+func (ah *AdminHandler) GetRateLimits(w http.ResponseWriter, r *http.Request) {
+	state, appErr := ah.RateLimitService.GetRateLimits(r.Context())
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Rate limits retrieved successfully", state))
+}
+
+func (ah *AdminHandler) SaveRateLimits(ctx context.Context, state ratelimit.State, takenAt time.Time) error {
+	if err := ah.RateLimitService.SaveRateLimits(ctx, state, takenAt); err != nil {
+		return err
+	}
+	return nil
+}
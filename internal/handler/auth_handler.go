@@ -1,20 +1,54 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/service"
 )
 
-func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+const (
+	accessTokenTTL = 15 * time.Minute
+	// refreshTokenPath keeps browsers from sending the refresh token anywhere but the auth routes
+	refreshTokenPath = "/api/v1/auth"
+)
+
+// RefreshTokenHandler exchanges a refresh token, from its cookie or the
+// request body, for a new access token and the next refresh token.
+func (uh *UserHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, refreshToken, appErr := uh.Service.RefreshSession(ctx, refreshTokenFromRequest(r))
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	tokens, err := setSessionCookies(w, user, refreshToken)
+	if err != nil {
+		common.RespondJSON(w, http.StatusInternalServerError, common.ErrorResponse("Failed to generate token"))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Token refreshed successfully", tokens))
+}
+
+// LogoutHandler revokes the session of the refresh token and clears the cookies.
+func (uh *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if appErr := uh.Service.Logout(r.Context(), refreshTokenFromRequest(r)); appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
 	expire := time.Unix(0, 0).UTC()
-	cookies := []string{"access_token", "refresh_token"}
-	for _, name := range cookies {
+	cookies := map[string]string{"access_token": "/", "refresh_token": refreshTokenPath}
+	for name, path := range cookies {
 		http.SetCookie(w, &http.Cookie{
 			Name:     name,
 			Value:    "",
-			Path:     "/",
+			Path:     path,
 			Expires:  expire,
 			MaxAge:   -1,
 			HttpOnly: true,
@@ -25,3 +59,44 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Logged out successfully", nil))
 }
+
+// setSessionCookies mints an access token for user and sets it in a cookie
+// along with refreshToken.
+func setSessionCookies(w http.ResponseWriter, user *domain.User, refreshToken string) (*domain.UserLoginResponseDTO, error) {
+	// Generate JWT token
+	accessToken, err := common.GenerateJWT(user.ID.String(), user.Role, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "access_token",
+		Value:    accessToken,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  time.Now().Add(accessTokenTTL),
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     refreshTokenPath,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  time.Now().Add(service.RefreshTokenTTL),
+	})
+
+	return &domain.UserLoginResponseDTO{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// refreshTokenFromRequest reads the refresh token cookie, falling back to the body.
+func refreshTokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	var tokenDTO domain.RefreshTokenRequestDTO
+	_ = json.NewDecoder(r.Body).Decode(&tokenDTO)
+	return tokenDTO.RefreshToken
+}
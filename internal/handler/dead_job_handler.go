@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type DeadJobHandler struct {
+	Service service.DeadJobService
+}
+
+func (dh *DeadJobHandler) ListDeadJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deadJobs, appErr := dh.Service.ListDeadJobs(ctx, limit)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Dead jobs retrieved successfully", deadJobs))
+}
+
+func (dh *DeadJobHandler) GetDeadJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	deadJob, appErr := dh.Service.GetDeadJob(ctx, jobID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Dead job retrieved successfully", deadJob))
+}
+
+// RequeueDeadJob requeues the dead job named in the URL.
+func (dh *DeadJobHandler) RequeueDeadJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	result, appErr := dh.Service.RequeueDeadJobs(ctx, []uuid.UUID{jobID})
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+	if result.Count == 0 {
+		common.RespondJSON(w, http.StatusNotFound, common.ErrorResponse("Dead job not found"))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Dead job requeued successfully", result))
+}
+
+// RequeueDeadJobs requeues every dead job listed in the request body.
+func (dh *DeadJobHandler) RequeueDeadJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var idsDTO domain.DeadJobIDsRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&idsDTO); err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	result, appErr := dh.Service.RequeueDeadJobs(ctx, idsDTO.JobIDs)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Dead jobs requeued successfully", result))
+}
+
+// PurgeDeadJob removes the dead job named in the URL.
+func (dh *DeadJobHandler) PurgeDeadJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	result, appErr := dh.Service.PurgeDeadJobs(ctx, []uuid.UUID{jobID})
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+	if result.Count == 0 {
+		common.RespondJSON(w, http.StatusNotFound, common.ErrorResponse("Dead job not found"))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Dead job purged successfully", result))
+}
+
+// PurgeDeadJobs removes the dead jobs listed in the request body, or all of
+// them when the body is empty.
+func (dh *DeadJobHandler) PurgeDeadJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var idsDTO domain.DeadJobIDsRequestDTO
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&idsDTO); err != nil {
+			common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+			return
+		}
+	}
+
+	result, appErr := dh.Service.PurgeDeadJobs(ctx, idsDTO.JobIDs)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Dead jobs purged successfully", result))
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type JobBatchHandler struct {
+	Service service.JobBatchService
+}
+
+func (bh *JobBatchHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var batchDTO domain.JobBatchCreateRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&batchDTO); err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	batchResponse, appErr := bh.Service.CreateBatch(ctx, batchDTO)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Batch created successfully", batchResponse))
+}
+
+func (bh *JobBatchHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	batchID, err := uuid.Parse(chi.URLParam(r, "batch_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Batch ID format"))
+		return
+	}
+
+	batch, appErr := bh.Service.GetBatch(ctx, batchID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Batch retrieved successfully", batch))
+}
+
+func (bh *JobBatchHandler) GetBatchProgress(ctx context.Context, batchID uuid.UUID) (*domain.JobBatch, error) {
+	batch, appErr := bh.Service.GetBatchProgress(ctx, batchID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return batch, nil
+}
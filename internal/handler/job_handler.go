@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Nezent/go-queue/common"
 	"github.com/Nezent/go-queue/internal/domain"
 	"github.com/Nezent/go-queue/internal/service"
 	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
@@ -25,9 +29,16 @@ func (jh *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobResponse, err := jh.Service.CreateJob(ctx, jobDTO)
-	if err != nil {
-		common.RespondJSON(w, http.StatusInternalServerError, common.ErrorResponse(err))
+	jobDTO.IdempotencyKey = r.Header.Get("Idempotency-Key")
+
+	jobResponse, appErr := jh.Service.CreateJob(ctx, jobDTO)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+	if jobResponse.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+		common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job already created", jobResponse))
 		return
 	}
 
@@ -45,27 +56,185 @@ func (jh *JobHandler) GetJobPayload(ctx context.Context, jobID uuid.UUID) (*task
 	return jobPayload, nil
 }
 
+func (jh *JobHandler) GetRecoverableJobs(ctx context.Context) ([]task.JobPayload, error) {
+	jobs, err := jh.Service.GetRecoverableJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (jh *JobHandler) ClaimJobs(ctx context.Context, workerID string, lease time.Duration, limit int) ([]task.JobPayload, error) {
+	jobs, err := jh.Service.ClaimJobs(ctx, workerID, lease, limit)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (jh *JobHandler) RenewJobLease(ctx context.Context, jobID uuid.UUID, workerID string, lease time.Duration) error {
+	if appErr := jh.Service.RenewJobLease(ctx, jobID, workerID, lease); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func (jh *JobHandler) FinishClaimedJob(ctx context.Context, jobID uuid.UUID, workerID string, status string, runAt time.Time, lastError string, result json.RawMessage) error {
+	if appErr := jh.Service.FinishClaimedJob(ctx, jobID, workerID, status, runAt, lastError, result); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
 func (jh *JobHandler) GetJobStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	jobIDStr := r.URL.Query().Get("job_id")
-	if jobIDStr == "" {
-		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Job ID is required"))
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	jobStatus, appErr := jh.Service.GetJobStatus(ctx, jobID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
 		return
 	}
 
-	jobID, err := uuid.Parse(jobIDStr)
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job status retrieved successfully", jobStatus))
+}
+
+func (jh *JobHandler) UpdateJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
 	if err != nil {
 		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
 		return
 	}
 
-	jobStatus, appErr := jh.Service.GetJobStatus(ctx, jobID)
+	var jobDTO domain.JobUpdateRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&jobDTO); err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	job, appErr := jh.Service.UpdateJob(ctx, jobID, jobDTO)
 	if appErr != nil {
-		common.RespondJSON(w, http.StatusInternalServerError, common.ErrorResponse(appErr))
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
 		return
 	}
 
-	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job status retrieved successfully", jobStatus))
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job updated successfully", job))
+}
+
+// ListJobs lists the user's jobs. List filters take comma separated values
+// and may be repeated, payload fields are matched with payload.<path>=<value>.
+func (jh *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	count, _ := strconv.ParseBool(query.Get("count"))
+
+	listDTO := domain.JobListRequestDTO{
+		Statuses:    splitQueryList(query["status"]),
+		Types:       splitQueryList(query["type"]),
+		Priorities:  splitQueryList(query["priority"]),
+		RunAtFrom:   query.Get("run_at_from"),
+		RunAtTo:     query.Get("run_at_to"),
+		CreatedFrom: query.Get("created_at_from"),
+		CreatedTo:   query.Get("created_at_to"),
+		Payload:     map[string]string{},
+		Sort:        query.Get("sort"),
+		Cursor:      query.Get("cursor"),
+		Limit:       limit,
+		Count:       count,
+	}
+	for key, values := range query {
+		if path, ok := strings.CutPrefix(key, "payload."); ok {
+			listDTO.Payload[path] = values[0]
+		}
+	}
+
+	jobs, appErr := jh.Service.ListJobs(ctx, listDTO)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Jobs retrieved successfully", jobs))
+}
+
+// splitQueryList flattens repeated and comma separated query values.
+func splitQueryList(values []string) []string {
+	list := []string{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// GetQueueStats reports how many jobs of all users are in each status.
+func (jh *JobHandler) GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	stats, appErr := jh.Service.GetQueueStats(r.Context())
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Queue stats retrieved successfully", stats))
+}
+
+func (jh *JobHandler) GetJobGraph(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	graph, appErr := jh.Service.GetJobGraph(ctx, jobID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job graph retrieved successfully", graph))
+}
+
+func (jh *JobHandler) GetJobEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	events, appErr := jh.Service.GetJobEvents(ctx, jobID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job events retrieved successfully", events))
+}
+
+func (jh *JobHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := uuid.Parse(chi.URLParam(r, "job_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Job ID format"))
+		return
+	}
+
+	if appErr := jh.Service.CancelJob(ctx, jobID); appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Job cancelled successfully", nil))
 }
 
 func (jh *JobHandler) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, attempts int) *common.AppError {
@@ -77,3 +246,26 @@ func (jh *JobHandler) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, stat
 
 	return nil
 }
+
+func (jh *JobHandler) QueueJob(ctx context.Context, jobID uuid.UUID, attempts int) (json.RawMessage, *common.AppError) {
+	return jh.Service.QueueJob(ctx, jobID, attempts)
+}
+
+func (jh *JobHandler) RetryJob(ctx context.Context, jobID uuid.UUID, attempts int, runAt time.Time, lastError string) *common.AppError {
+	return jh.Service.RetryJob(ctx, jobID, attempts, runAt, lastError)
+}
+
+func (jh *JobHandler) FailJob(ctx context.Context, jobID uuid.UUID, attempts int, lastError string) *common.AppError {
+	return jh.Service.FailJob(ctx, jobID, attempts, lastError)
+}
+
+func (jh *JobHandler) SaveJobResult(ctx context.Context, jobID uuid.UUID, result json.RawMessage) *common.AppError {
+	return jh.Service.SaveJobResult(ctx, jobID, result)
+}
+
+func (jh *JobHandler) SaveJobProgress(ctx context.Context, jobID uuid.UUID, percent int, message string) error {
+	if appErr := jh.Service.SaveJobProgress(ctx, jobID, percent, message); appErr != nil {
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type JobScheduleHandler struct {
+	Service service.JobScheduleService
+}
+
+func (sh *JobScheduleHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var scheduleDTO domain.JobScheduleCreateRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&scheduleDTO); err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	jobSchedule, appErr := sh.Service.CreateSchedule(ctx, scheduleDTO)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Schedule created successfully", jobSchedule))
+}
+
+func (sh *JobScheduleHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	schedules, appErr := sh.Service.ListSchedules(ctx)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Schedules retrieved successfully", schedules))
+}
+
+func (sh *JobScheduleHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "schedule_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Schedule ID format"))
+		return
+	}
+
+	jobSchedule, appErr := sh.Service.GetSchedule(ctx, scheduleID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Schedule retrieved successfully", jobSchedule))
+}
+
+func (sh *JobScheduleHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "schedule_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Schedule ID format"))
+		return
+	}
+
+	var scheduleDTO domain.JobScheduleUpdateRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&scheduleDTO); err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	jobSchedule, appErr := sh.Service.UpdateSchedule(ctx, scheduleID, scheduleDTO)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Schedule updated successfully", jobSchedule))
+}
+
+func (sh *JobScheduleHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	scheduleID, err := uuid.Parse(chi.URLParam(r, "schedule_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid Schedule ID format"))
+		return
+	}
+
+	if appErr := sh.Service.DeleteSchedule(ctx, scheduleID); appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Schedule deleted successfully", nil))
+}
+
+func (sh *JobScheduleHandler) ClaimDueSchedules(ctx context.Context, limit int) ([]domain.JobSchedule, *common.AppError) {
+	return sh.Service.ClaimDueSchedules(ctx, limit)
+}
+
+func (sh *JobScheduleHandler) MaterializeSchedule(ctx context.Context, jobSchedule domain.JobSchedule, runAts []time.Time, nextRunAt time.Time) *common.AppError {
+	return sh.Service.MaterializeSchedule(ctx, jobSchedule, runAts, nextRunAt)
+}
@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/Nezent/go-queue/common"
 	"github.com/Nezent/go-queue/internal/domain"
 	"github.com/Nezent/go-queue/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type UserHandler struct {
@@ -46,31 +49,25 @@ func (uh *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the service to login the user
-	userID, err := uh.Service.LoginUser(ctx, userDTO)
+	user, err := uh.Service.LoginUser(ctx, userDTO)
 	if err != nil {
 		common.RespondJSON(w, http.StatusUnauthorized, common.ErrorResponse("Invalid email or password"))
 		return
 	}
 
-	// Generate JWT token
-	accessToken, appError := common.GenerateJWT(userID.String(), "user", time.Minute*15)
+	refreshToken, appErr := uh.Service.IssueRefreshToken(ctx, user.ID)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	tokens, appError := setSessionCookies(w, user, refreshToken)
 	if appError != nil {
 		common.RespondJSON(w, http.StatusInternalServerError, common.ErrorResponse("Failed to generate token"))
 		return
 	}
 
-	// Set the access token in a cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "access_token",
-		Value:    accessToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-		Expires:  time.Now().Add(time.Minute * 15),
-	})
-
-	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Login successful", domain.UserLoginResponseDTO{AccessToken: accessToken}))
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Login successful", tokens))
 
 }
 
@@ -87,3 +84,66 @@ func (uh *UserHandler) VerifyUser(w http.ResponseWriter, r *http.Request) {
 
 	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("User verified successfully", nil))
 }
+
+func (uh *UserHandler) GetWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	secret, appErr := uh.Service.GetWebhookSecret(r.Context())
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Webhook secret retrieved successfully", secret))
+}
+
+func (uh *UserHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	secret, appErr := uh.Service.RotateWebhookSecret(r.Context())
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Webhook secret rotated successfully", secret))
+}
+
+func (uh *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	users, appErr := uh.Service.ListUsers(r.Context(), limit)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("Users retrieved successfully", users))
+}
+
+func (uh *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid User ID format"))
+		return
+	}
+	var roleDTO domain.UserRoleUpdateRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&roleDTO); err != nil {
+		common.RespondJSON(w, http.StatusBadRequest, common.ErrorResponse("Invalid request payload"))
+		return
+	}
+
+	user, appErr := uh.Service.UpdateUserRole(ctx, userID, roleDTO)
+	if appErr != nil {
+		common.RespondJSON(w, appErr.StatusCode, common.ErrorResponse(appErr))
+		return
+	}
+
+	common.RespondJSON(w, http.StatusOK, common.SuccessResponse("User role updated successfully", user))
+}
+
+// WebhookSecret returns the key a user's http:request jobs are signed with.
+func (uh *UserHandler) WebhookSecret(ctx context.Context, userID uuid.UUID) (string, error) {
+	secret, appErr := uh.Service.LookupWebhookSecret(ctx, userID)
+	if appErr != nil {
+		return "", appErr
+	}
+	return secret, nil
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 
 	"github.com/Nezent/go-queue/common"
 )
@@ -37,6 +38,14 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
 		ctx = context.WithValue(ctx, RoleKey, role)
 
+		// Act as the user for the job_events trigger and row level security
+		if tx, err := GetTxFromContext(ctx); err == nil {
+			if err := SetTxSession(ctx, tx); err != nil {
+				common.RespondJSON(w, http.StatusInternalServerError, common.ErrorResponse("Failed to prepare transaction"))
+				return
+			}
+		}
+
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -52,3 +61,18 @@ func GetUserRole(ctx context.Context) (string, bool) {
 	role, ok := ctx.Value(RoleKey).(string)
 	return role, ok
 }
+
+// RequireRole only lets requests through whose token carries one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := GetUserRole(r.Context())
+			if !slices.Contains(roles, role) {
+				common.RespondJSON(w, http.StatusForbidden, common.ErrorResponse("Forbidden - insufficient role"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
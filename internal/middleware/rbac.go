@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/Nezent/go-queue/common"
+)
+
+// Roles a user can have, stored in users.role.
+const (
+	RoleUser     = "user"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Permission is something a role allows beyond managing one's own jobs.
+type Permission string
+
+const (
+	// PermissionAllJobs allows reading and changing the jobs of every user
+	PermissionAllJobs Permission = "jobs:all"
+	// PermissionViewQueue allows inspecting the state of the queue
+	PermissionViewQueue Permission = "queue:view"
+	// PermissionManageUsers allows listing users and changing their roles
+	PermissionManageUsers Permission = "users:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleUser:     {},
+	RoleOperator: {PermissionViewQueue},
+	RoleAdmin:    {PermissionAllJobs, PermissionViewQueue, PermissionManageUsers},
+}
+
+// ValidRole reports whether role is a known role.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission.
+func HasPermission(role string, permission Permission) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// RequirePermission only lets requests through whose role grants every one of
+// permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := GetUserRole(r.Context())
+			for _, permission := range permissions {
+				if !HasPermission(role, permission) {
+					common.RespondJSON(w, http.StatusForbidden, common.ErrorResponse("Forbidden - missing permission "+string(permission)))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
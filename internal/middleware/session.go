@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const ActorKey ctxKey = "actor"
+
+// WithActor attributes the job changes made with ctx to actor, e.g. "scheduler".
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, ActorKey, actor)
+}
+
+// GetActor names who acts with ctx: the actor set by WithActor, else the
+// authenticated user as "user:<id>", else "system".
+func GetActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(ActorKey).(string); ok && actor != "" {
+		return actor
+	}
+	if userID, ok := GetUserID(ctx); ok && userID != "" {
+		return "user:" + userID
+	}
+	return "system"
+}
+
+// SetTxSession records who acts with ctx in transaction local settings of tx:
+// app.actor for the job_events trigger, app.user_id and app.role for the row
+// level security policies. Transactions without a user see every row.
+func SetTxSession(ctx context.Context, tx pgx.Tx) error {
+	userID, _ := GetUserID(ctx)
+	role, _ := GetUserRole(ctx)
+	_, err := tx.Exec(ctx, `
+		SELECT set_config('app.actor', $1, true), set_config('app.user_id', $2, true), set_config('app.role', $3, true)
+	`, GetActor(ctx), userID, role)
+	return err
+}
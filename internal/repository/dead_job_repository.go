@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DeadJobRepository interface {
+	// ListDeadJobs retrieves a user's dead jobs, most recently failed first.
+	ListDeadJobs(context.Context, uuid.UUID, int) ([]domain.DeadJob, *common.AppError)
+	// GetDeadJob retrieves one of a user's dead jobs.
+	GetDeadJob(context.Context, uuid.UUID, uuid.UUID) (*domain.DeadJob, *common.AppError)
+	// RequeueDeadJobs resets the given dead jobs to pending and removes them from the queue.
+	RequeueDeadJobs(context.Context, uuid.UUID, []uuid.UUID) ([]uuid.UUID, *common.AppError)
+	// PurgeDeadJobs removes the given dead jobs, or all of the user's if none are given.
+	PurgeDeadJobs(context.Context, uuid.UUID, []uuid.UUID) ([]uuid.UUID, *common.AppError)
+}
+
+type deadJobRepository struct {
+	db *pgxpool.Pool
+}
+
+const deadJobColumns = `job_id, user_id, type, payload, attempts, last_error, attempt_history, failed_at`
+
+func scanDeadJob(row pgx.Row) (*domain.DeadJob, error) {
+	var job domain.DeadJob
+	err := row.Scan(
+		&job.JobID, &job.UserID, &job.Type, &job.Payload,
+		&job.Attempts, &job.LastError, &job.AttemptHistory, &job.FailedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (dr deadJobRepository) ListDeadJobs(ctx context.Context, userID uuid.UUID, limit int) ([]domain.DeadJob, *common.AppError) {
+	query := `
+		SELECT ` + deadJobColumns + ` FROM dead_jobs
+		WHERE user_id = $1
+		ORDER BY failed_at DESC
+		LIMIT $2
+	`
+	rows, err := dr.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve dead jobs", err)
+	}
+	defer rows.Close()
+
+	jobs := []domain.DeadJob{}
+	for rows.Next() {
+		job, err := scanDeadJob(rows)
+		if err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan dead job", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve dead jobs", err)
+	}
+	return jobs, nil
+}
+
+func (dr deadJobRepository) GetDeadJob(ctx context.Context, userID uuid.UUID, jobID uuid.UUID) (*domain.DeadJob, *common.AppError) {
+	query := `SELECT ` + deadJobColumns + ` FROM dead_jobs WHERE job_id = $1 AND user_id = $2`
+
+	job, err := scanDeadJob(dr.db.QueryRow(ctx, query, jobID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Dead job not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve dead job", err)
+	}
+	return job, nil
+}
+
+func (dr deadJobRepository) RequeueDeadJobs(ctx context.Context, userID uuid.UUID, jobIDs []uuid.UUID) ([]uuid.UUID, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// Resetting the row to pending fires job_updates, which pushes it back onto the heap
+	query := `
+		WITH requeued AS (
+			DELETE FROM dead_jobs WHERE job_id = ANY($1) AND user_id = $2
+			RETURNING job_id
+		)
+		UPDATE jobs SET status = 'pending', attempts = 0, run_at = now(), updated_at = now(),
+			result = NULL, started_at = NULL, finished_at = NULL
+		WHERE id IN (SELECT job_id FROM requeued)
+		RETURNING id
+	`
+	return collectJobIDs(tx.Query(ctx, query, jobIDs, userID))
+}
+
+func (dr deadJobRepository) PurgeDeadJobs(ctx context.Context, userID uuid.UUID, jobIDs []uuid.UUID) ([]uuid.UUID, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	query := `
+		DELETE FROM dead_jobs
+		WHERE user_id = $1 AND (coalesce(cardinality($2::uuid[]), 0) = 0 OR job_id = ANY($2))
+		RETURNING job_id
+	`
+	return collectJobIDs(tx.Query(ctx, query, userID, jobIDs))
+}
+
+// collectJobIDs reads a single UUID column from every row.
+func collectJobIDs(rows pgx.Rows, err error) ([]uuid.UUID, *common.AppError) {
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to update dead jobs", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to update dead jobs", err)
+	}
+	return ids, nil
+}
+
+func NewDeadJobRepository(db *pgxpool.Pool) deadJobRepository {
+	return deadJobRepository{db: db}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobBatchRepository interface {
+	// CreateBatch creates an empty batch expecting total jobs.
+	CreateBatch(context.Context, domain.JobBatch) (*domain.JobBatch, *common.AppError)
+	// GetBatch retrieves one of a user's batches with its counts.
+	GetBatch(context.Context, uuid.UUID, uuid.UUID) (*domain.JobBatch, *common.AppError)
+	// GetBatchProgress retrieves a batch with its counts, whoever owns it.
+	GetBatchProgress(context.Context, uuid.UUID) (*domain.JobBatch, *common.AppError)
+}
+
+type jobBatchRepository struct {
+	db *pgxpool.Pool
+}
+
+// jobBatchQuery selects a batch with the counts of its members' statuses.
+const jobBatchQuery = `
+	SELECT b.id, b.user_id, b.total,
+		count(j.id) FILTER (WHERE j.status NOT IN ('completed', 'failed', 'cancelled', 'skipped')),
+		count(j.id) FILTER (WHERE j.status = 'completed'),
+		count(j.id) FILTER (WHERE j.status IN ('failed', 'cancelled', 'skipped')),
+		b.on_complete_job_id, b.completed_at, b.created_at
+	FROM job_batches b
+	LEFT JOIN jobs j ON j.batch_id = b.id
+`
+
+func scanJobBatch(row pgx.Row) (*domain.JobBatch, error) {
+	var batch domain.JobBatch
+	err := row.Scan(
+		&batch.ID, &batch.UserID, &batch.Total,
+		&batch.Pending, &batch.Completed, &batch.Failed,
+		&batch.OnCompleteJobID, &batch.CompletedAt, &batch.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (br jobBatchRepository) CreateBatch(ctx context.Context, batch domain.JobBatch) (*domain.JobBatch, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	query := `
+		INSERT INTO job_batches (user_id, total, on_complete_job_id)
+		VALUES ($1, $2, $3) RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, query, batch.UserID, batch.Total, batch.OnCompleteJobID).Scan(&batch.ID, &batch.CreatedAt)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to create batch", err)
+	}
+	batch.Pending = batch.Total
+	return &batch, nil
+}
+
+func (br jobBatchRepository) GetBatch(ctx context.Context, userID uuid.UUID, batchID uuid.UUID) (*domain.JobBatch, *common.AppError) {
+	query := jobBatchQuery + ` WHERE b.id = $1 AND b.user_id = $2 GROUP BY b.id`
+
+	batch, err := scanJobBatch(br.db.QueryRow(ctx, query, batchID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Batch not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve batch", err)
+	}
+	return batch, nil
+}
+
+func (br jobBatchRepository) GetBatchProgress(ctx context.Context, batchID uuid.UUID) (*domain.JobBatch, *common.AppError) {
+	query := jobBatchQuery + ` WHERE b.id = $1 GROUP BY b.id`
+
+	batch, err := scanJobBatch(br.db.QueryRow(ctx, query, batchID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Batch not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve batch", err)
+	}
+	return batch, nil
+}
+
+func NewJobBatchRepository(db *pgxpool.Pool) jobBatchRepository {
+	return jobBatchRepository{
+		db: db,
+	}
+}
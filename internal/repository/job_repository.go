@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/Nezent/go-queue/common"
@@ -11,23 +14,105 @@ import (
 	"github.com/Nezent/go-queue/internal/middleware"
 	"github.com/Nezent/go-queue/internal/worker/task"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type JobRepository interface {
 	// CreateJob creates a new job in the database.
 	CreateJob(context.Context, domain.Job) (*domain.Job, *common.AppError)
+	// CheckJobDependencies makes sure every job in the list exists and belongs to the user.
+	CheckJobDependencies(context.Context, uuid.UUID, []uuid.UUID) *common.AppError
 	// GetJobPayload retrieves a job payload by its ID.
 	GetJobPayload(context.Context, uuid.UUID) (*task.JobPayload, *common.AppError)
+	// GetRecoverableJobs retrieves every job that has not reached a terminal state.
+	GetRecoverableJobs(context.Context) ([]task.JobPayload, *common.AppError)
+	// ClaimJobs leases up to limit due jobs to a worker using SKIP LOCKED.
+	ClaimJobs(context.Context, string, time.Duration, int) ([]task.JobPayload, *common.AppError)
+	// RenewJobLease extends the lease a worker holds on a running job.
+	RenewJobLease(context.Context, uuid.UUID, string, time.Duration) *common.AppError
+	// FinishClaimedJob releases a leased job with its outcome, result and next run time.
+	FinishClaimedJob(context.Context, uuid.UUID, string, string, time.Time, string, json.RawMessage) *common.AppError
+	// SaveJobResult stores what a job's handler produced.
+	SaveJobResult(context.Context, uuid.UUID, json.RawMessage) *common.AppError
+	// SaveJobProgress stores the progress a running job's handler reported.
+	SaveJobProgress(context.Context, uuid.UUID, int, string) *common.AppError
 	// // UpdateJobStatus updates an existing job status in the database.
 	UpdateJobStatus(context.Context, uuid.UUID, string, int) (*domain.Job, *common.AppError)
-	// GetJobStatus retrieves the status of a job by its ID.
-	GetJobStatus(context.Context, uuid.UUID) (*domain.JobStatusResponseDTO, *common.AppError)
+	// QueueJob marks a job as queued for dispatch and returns its current payload.
+	QueueJob(context.Context, uuid.UUID, int) (json.RawMessage, *common.AppError)
+	// RetryJob marks a failed job as retrying and moves it to its next run time.
+	RetryJob(context.Context, uuid.UUID, int, time.Time, string) *common.AppError
+	// FailJob marks a job as failed for good and moves it to the dead-letter queue.
+	FailJob(context.Context, uuid.UUID, int, string) *common.AppError
+	// The methods below take the owner of the job to access; a nil owner,
+	// used for admins, can access the jobs of every user.
+
+	// UpdateJob changes the payload, priority or run time of a pending job of the owner.
+	UpdateJob(context.Context, *uuid.UUID, uuid.UUID, map[string]any, *string, *time.Time) (*domain.Job, *common.AppError)
+	// GetJobGraph retrieves every job connected to a job of the owner through dependencies.
+	GetJobGraph(context.Context, *uuid.UUID, uuid.UUID) (*domain.JobGraphResponseDTO, *common.AppError)
+	// ListJobs retrieves a page of a user's jobs matching the filter.
+	ListJobs(context.Context, uuid.UUID, domain.JobFilter) ([]domain.Job, *common.AppError)
+	// CountJobs counts a user's jobs matching the filter, ignoring its page.
+	CountJobs(context.Context, uuid.UUID, domain.JobFilter) (int, *common.AppError)
+	// CountJobsByStatus counts the jobs of every user by status.
+	CountJobsByStatus(context.Context) (map[string]int, *common.AppError)
+	// GetJobEvents retrieves the timeline of a job of the owner, oldest event first.
+	GetJobEvents(context.Context, *uuid.UUID, uuid.UUID) ([]domain.JobEvent, *common.AppError)
+	// CancelJob marks a job of the owner as cancelled and returns its previous status.
+	CancelJob(context.Context, *uuid.UUID, uuid.UUID) (string, *common.AppError)
+	// GetJobStatus retrieves the status of a job of the owner by its ID.
+	GetJobStatus(context.Context, *uuid.UUID, uuid.UUID) (*domain.JobStatusResponseDTO, *common.AppError)
 }
 type jobRepository struct {
 	db *pgxpool.Pool
 }
 
+// ownedBy restricts a query to the jobs of the owner passed as $2, or to every
+// job if it is NULL.
+const ownedBy = `($2::uuid IS NULL OR user_id = $2)`
+
+// querier is what the pool and transactions have in common for reads.
+type querier interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+// conn returns the transaction of a request, so its reads are subject to row
+// level security, or the pool for callers without one.
+func (jr jobRepository) conn(ctx context.Context) querier {
+	if tx, err := middleware.GetTxFromContext(ctx); err == nil {
+		return tx
+	}
+	return jr.db
+}
+
+// jobPayloadColumns lists the columns scanJobPayload reads, in order.
+const jobPayloadColumns = `id, type, payload, status, priority, attempts, run_at, retry_policy, batch_id, COALESCE(user_id, uuid_nil())`
+
+// scanJobPayload reads a row selected with jobPayloadColumns. The payload is
+// kept as raw JSON, only the job type's handler knows how to decode it.
+func scanJobPayload(row pgx.Row) (*task.JobPayload, error) {
+	var payload task.JobPayload
+	err := row.Scan(
+		&payload.ID,
+		&payload.JobType,
+		&payload.Payload,
+		&payload.Status,
+		&payload.Priority,
+		&payload.Attempts,
+		&payload.RunAt,
+		&payload.Retry,
+		&payload.BatchID,
+		&payload.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
 func (jr jobRepository) CreateJob(ctx context.Context, job domain.Job) (*domain.Job, *common.AppError) {
 	// Extract transaction from context
 	tx, err := middleware.GetTxFromContext(ctx)
@@ -35,13 +120,36 @@ func (jr jobRepository) CreateJob(ctx context.Context, job domain.Job) (*domain.
 		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
 	}
 
-	// Insert into database
+	// Check the dependencies first, the transaction is committed even if the request fails
+	dependsOn := make([]uuid.UUID, len(job.DependsOn))
+	onFailure := make([]string, len(job.DependsOn))
+	for i, dependency := range job.DependsOn {
+		dependsOn[i] = dependency.JobID
+		onFailure[i] = dependency.OnFailure
+	}
+	if appErr := jr.CheckJobDependencies(ctx, job.UserID, dependsOn); appErr != nil {
+		return nil, appErr
+	}
+
+	// Insert into database, the idempotency index and the uniqueness constraint turn replays into no-ops
 	query := `
-		INSERT INTO jobs (user_id, type, payload, status, priority, attempts, run_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+		INSERT INTO jobs (user_id, type, payload, status, priority, attempts, run_at, retry_policy, batch_id,
+			idempotency_key, unique_key, unique_window)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''),
+			CASE WHEN $11 = '' THEN NULL
+				WHEN $12::float8 = 0 THEN tstzrange(now(), NULL)
+				ELSE tstzrange(now(), now() + $12::float8 * interval '1 second') END)
+		ON CONFLICT DO NOTHING
+		RETURNING id
 	`
 	run_at := job.RunAt
-	job.Status = "pending"
+	if job.Status == "" {
+		job.Status = "pending"
+	}
+	if len(dependsOn) > 0 {
+		// Kept out of the scheduler until its parents allow it to run
+		job.Status = "blocked"
+	}
 	job.CreatedAt = time.Now().In(common.DhakaTZ)
 	job.UpdatedAt = time.Now().In(common.DhakaTZ)
 	job.Attempts = 0
@@ -49,43 +157,239 @@ func (jr jobRepository) CreateJob(ctx context.Context, job domain.Job) (*domain.
 	err = tx.QueryRow(ctx, query,
 		job.UserID, job.Type, job.Payload,
 		job.Status, job.Priority, job.Attempts,
-		run_at,
+		run_at, job.RetryPolicy, job.BatchID,
+		job.IdempotencyKey, job.UniqueKey, time.Duration(job.UniqueWindow).Seconds(),
 	).Scan(&jobID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return jr.getReplayedJob(ctx, tx, job)
+	}
 	if err != nil {
 		return nil, common.NewUnexpectedServerError("Failed to create job", err)
 	}
 	job.ID = jobID
+
+	if len(dependsOn) > 0 {
+		query = `
+			INSERT INTO job_dependencies (job_id, depends_on, on_failure)
+			SELECT $1, depends_on, on_failure FROM unnest($2::uuid[], $3::text[]) AS d(depends_on, on_failure)
+		`
+		if _, err := tx.Exec(ctx, query, jobID, dependsOn, onFailure); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to create job dependencies", err)
+		}
+
+		// Parents may have finished already
+		if _, err := tx.Exec(ctx, `SELECT resolve_blocked_job($1)`, jobID); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to resolve job dependencies", err)
+		}
+		if err := tx.QueryRow(ctx, `SELECT status FROM jobs WHERE id = $1`, jobID).Scan(&job.Status); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to resolve job dependencies", err)
+		}
+	}
 	return &job, nil
 }
 
-func (jr jobRepository) GetJobPayload(ctx context.Context, jobID uuid.UUID) (*task.JobPayload, *common.AppError) {
+func (jr jobRepository) CheckJobDependencies(ctx context.Context, userID uuid.UUID, dependsOn []uuid.UUID) *common.AppError {
+	if len(dependsOn) == 0 {
+		return nil
+	}
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	var found int
+	err = tx.QueryRow(ctx, `SELECT count(*) FROM jobs WHERE id = ANY($1) AND user_id = $2`, dependsOn, userID).Scan(&found)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to check job dependencies", err)
+	}
+	if found != len(dependsOn) {
+		return common.NewNotFoundError("Dependency job not found")
+	}
+	return nil
+}
+
+// getReplayedJob returns the job a conflicting CreateJob collided with.
+func (jr jobRepository) getReplayedJob(ctx context.Context, tx pgx.Tx, job domain.Job) (*domain.Job, *common.AppError) {
+	// The window is built as in CreateJob and matched the way the exclusion
+	// constraint does: a concurrent insert committed after this transaction
+	// began has a window starting after now(), which overlaps but doesn't contain it
 	query := `
-		SELECT type, payload, status, priority, attempts, run_at FROM jobs WHERE id = $1
+		SELECT id, user_id, type, payload, status, priority, attempts, run_at, created_at, updated_at,
+			retry_policy, batch_id, COALESCE(idempotency_key, ''), COALESCE(unique_key, ''),
+			result, COALESCE(last_error, ''), started_at, finished_at
+		FROM jobs
+		WHERE user_id = $1 AND (
+			($2 <> '' AND idempotency_key = $2)
+			OR ($3 <> '' AND unique_key = $3 AND unique_window && CASE
+				WHEN $4::float8 = 0 THEN tstzrange(now(), NULL)
+				ELSE tstzrange(now(), now() + $4::float8 * interval '1 second') END)
+		)
+		ORDER BY (idempotency_key = $2) IS TRUE DESC, lower(unique_window)
+		LIMIT 1
 	`
-
-	var payload task.JobPayload
-	var rawPayload []byte // payload column as JSON
-	err := jr.db.QueryRow(ctx, query, jobID).Scan(
-		&payload.JobType,
-		&rawPayload,
-		&payload.Status,
-		&payload.Priority,
-		&payload.Attempts,
-		&payload.RunAt,
+	existing := domain.Job{Replayed: true}
+	err := tx.QueryRow(ctx, query,
+		job.UserID, job.IdempotencyKey, job.UniqueKey, time.Duration(job.UniqueWindow).Seconds(),
+	).Scan(
+		&existing.ID, &existing.UserID, &existing.Type, &existing.Payload, &existing.Status,
+		&existing.Priority, &existing.Attempts, &existing.RunAt, &existing.CreatedAt, &existing.UpdatedAt,
+		&existing.RetryPolicy, &existing.BatchID, &existing.IdempotencyKey, &existing.UniqueKey,
+		&existing.Result, &existing.LastError, &existing.StartedAt, &existing.FinishedAt,
 	)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve replayed job", err)
+	}
+	return &existing, nil
+}
+
+func (jr jobRepository) GetJobPayload(ctx context.Context, jobID uuid.UUID) (*task.JobPayload, *common.AppError) {
+	query := `SELECT ` + jobPayloadColumns + ` FROM jobs WHERE id = $1`
+
+	payload, err := scanJobPayload(jr.db.QueryRow(ctx, query, jobID))
 	if err != nil {
 		log.Printf("[DEBUG] QueryRow scan failed for jobID %s: %v", jobID, err)
 		return nil, common.NewUnexpectedServerError("Failed to retrieve job payload", err)
 	}
 
-	// Unmarshal JSON payload
-	var emailPayload task.EmailPayload
-	if err := json.Unmarshal(rawPayload, &emailPayload); err != nil {
-		return nil, common.NewUnexpectedServerError("Failed to parse job payload JSON", err)
+	return payload, nil
+}
+
+func (jr jobRepository) GetRecoverableJobs(ctx context.Context) ([]task.JobPayload, *common.AppError) {
+	// "retrying" jobs are waiting in the heap for their next attempt, so they are
+	// recovered too. "queued" jobs may never have reached the broker if the
+	// scheduler stopped right after queueing them
+	query := `
+		SELECT ` + jobPayloadColumns + `
+		FROM jobs WHERE status IN ('pending', 'retrying', 'queued')
+		ORDER BY run_at
+	`
+	rows, err := jr.db.Query(ctx, query)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve recoverable jobs", err)
 	}
+	defer rows.Close()
 
-	payload.Payload = emailPayload
-	return &payload, nil
+	jobs := []task.JobPayload{}
+	for rows.Next() {
+		payload, err := scanJobPayload(rows)
+		if err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan recoverable job", err)
+		}
+		jobs = append(jobs, *payload)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve recoverable jobs", err)
+	}
+	return jobs, nil
+}
+
+func (jr jobRepository) ClaimJobs(ctx context.Context, workerID string, lease time.Duration, limit int) ([]task.JobPayload, *common.AppError) {
+	// Due pending jobs and jobs whose lease expired are claimed in a single statement,
+	// SKIP LOCKED lets concurrent workers claim disjoint rows without blocking
+	query := `
+		UPDATE jobs SET
+			status = 'processing',
+			attempts = attempts + 1,
+			locked_by = $1,
+			locked_until = now() + $2 * interval '1 second',
+			updated_at = now()
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE (status IN ('pending', 'retrying') AND run_at <= now())
+				OR (status = 'processing' AND locked_until < now())
+			ORDER BY run_at,
+				CASE priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobPayloadColumns + `
+	`
+	tx, err := jr.db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := middleware.SetTxSession(ctx, tx); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to claim jobs", err)
+	}
+
+	rows, err := tx.Query(ctx, query, workerID, lease.Seconds(), limit)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to claim jobs", err)
+	}
+	defer rows.Close()
+
+	jobs := []task.JobPayload{}
+	for rows.Next() {
+		payload, err := scanJobPayload(rows)
+		if err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan claimed job", err)
+		}
+		jobs = append(jobs, *payload)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to claim jobs", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to claim jobs", err)
+	}
+	return jobs, nil
+}
+
+func (jr jobRepository) RenewJobLease(ctx context.Context, jobID uuid.UUID, workerID string, lease time.Duration) *common.AppError {
+	// A job cancelled or taken over after its lease expired is no longer the worker's to renew
+	query := `
+		UPDATE jobs SET locked_until = now() + $3 * interval '1 second'
+		WHERE id = $1 AND locked_by = $2 AND status = 'processing'
+	`
+	tag, err := jr.db.Exec(ctx, query, jobID, workerID, lease.Seconds())
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to renew job lease", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return common.NewNotFoundError("Job lease was lost")
+	}
+	return nil
+}
+
+func (jr jobRepository) FinishClaimedJob(ctx context.Context, jobID uuid.UUID, workerID string, status string, runAt time.Time, lastError string, result json.RawMessage) *common.AppError {
+	tx, err := jr.db.Begin(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to start transaction", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := middleware.SetTxSession(ctx, tx); err != nil {
+		return common.NewUnexpectedServerError("Failed to release claimed job", err)
+	}
+
+	// Only the worker still holding the lease may release the job
+	query := `
+		UPDATE jobs SET status = $1, run_at = $2, locked_by = NULL, locked_until = NULL, updated_at = now(),
+			attempt_history = CASE WHEN $5 = '' THEN attempt_history
+				ELSE attempt_history || jsonb_build_array(jsonb_build_object('attempt', attempts, 'error', $5::text, 'failed_at', now()))
+			END,
+			last_error = CASE WHEN $5 = '' THEN last_error ELSE $5 END,
+			result = COALESCE($6, result)
+		WHERE id = $3 AND locked_by = $4
+	`
+	tag, err := tx.Exec(ctx, query, status, runAt, jobID, workerID, lastError, result)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to release claimed job", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return common.NewNotFoundError("Job lease was lost")
+	}
+
+	if status == "failed" {
+		if appErr := insertDeadJob(ctx, tx, jobID, lastError); appErr != nil {
+			return appErr
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return common.NewUnexpectedServerError("Failed to release claimed job", err)
+	}
+	return nil
 }
 
 func (jr jobRepository) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, attempts int) (*domain.Job, *common.AppError) {
@@ -97,10 +401,14 @@ func (jr jobRepository) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, st
 
 	// Update job status in database
 	query := `
-		UPDATE jobs SET status = $1, attempts = $2, updated_at = $3 WHERE id = $4 RETURNING *
+		UPDATE jobs SET status = $1, attempts = $2, updated_at = $3 WHERE id = $4 AND status <> 'cancelled'
+		RETURNING id, user_id, type, payload, status, priority, attempts, run_at, created_at, updated_at
 	`
 	job := domain.Job{}
 	err = tx.QueryRow(ctx, query, status, attempts, time.Now().In(common.DhakaTZ), jobID).Scan(&job.ID, &job.UserID, &job.Type, &job.Payload, &job.Status, &job.Priority, &job.Attempts, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Job not found or cancelled")
+	}
 	if err != nil {
 		return nil, common.NewUnexpectedServerError("Failed to update job status", err)
 	}
@@ -108,14 +416,428 @@ func (jr jobRepository) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, st
 	return &job, nil
 }
 
-func (jr jobRepository) GetJobStatus(ctx context.Context, jobID uuid.UUID) (*domain.JobStatusResponseDTO, *common.AppError) {
-	// Retrieve job status from database
+func (jr jobRepository) QueueJob(ctx context.Context, jobID uuid.UUID, attempts int) (json.RawMessage, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// UpdateJob locks the row of a pending job too, so the payload returned is
+	// the one of the latest edit, never one an edit replaced after the job was read
+	query := `
+		UPDATE jobs SET status = 'queued', attempts = $1, updated_at = $2 WHERE id = $3 AND status <> 'cancelled'
+		RETURNING payload
+	`
+	var payload json.RawMessage
+	err = tx.QueryRow(ctx, query, attempts, time.Now().In(common.DhakaTZ), jobID).Scan(&payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Job not found or cancelled")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to queue job", err)
+	}
+	return payload, nil
+}
+
+func (jr jobRepository) RetryJob(ctx context.Context, jobID uuid.UUID, attempts int, runAt time.Time, lastError string) *common.AppError {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// The next run time is persisted, so a recovered job keeps its backoff
+	query := `
+		UPDATE jobs SET status = 'retrying', attempts = $1, run_at = $2, updated_at = $3, last_error = $5,
+			attempt_history = attempt_history || jsonb_build_array(jsonb_build_object('attempt', $1::int, 'error', $5::text, 'failed_at', $3::timestamptz))
+		WHERE id = $4 AND status <> 'cancelled'
+	`
+	_, err = tx.Exec(ctx, query, attempts, runAt, time.Now().In(common.DhakaTZ), jobID, lastError)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to schedule job retry", err)
+	}
+	return nil
+}
+
+func (jr jobRepository) FailJob(ctx context.Context, jobID uuid.UUID, attempts int, lastError string) *common.AppError {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
 	query := `
-		SELECT type, status, priority, attempts, run_at
+		UPDATE jobs SET status = 'failed', attempts = $1, updated_at = $2, last_error = $4,
+			attempt_history = attempt_history || jsonb_build_array(jsonb_build_object('attempt', $1::int, 'error', $4::text, 'failed_at', $2::timestamptz))
+		WHERE id = $3 AND status <> 'cancelled'
+	`
+	tag, err := tx.Exec(ctx, query, attempts, time.Now().In(common.DhakaTZ), jobID, lastError)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to mark job as failed", err)
+	}
+	if tag.RowsAffected() == 0 {
+		// A cancelled job is not dead-lettered
+		return nil
+	}
+	return insertDeadJob(ctx, tx, jobID, lastError)
+}
+
+func (jr jobRepository) SaveJobResult(ctx context.Context, jobID uuid.UUID, result json.RawMessage) *common.AppError {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE jobs SET result = $1, updated_at = $2 WHERE id = $3`, result, time.Now().In(common.DhakaTZ), jobID)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to save job result", err)
+	}
+	return nil
+}
+
+func (jr jobRepository) SaveJobProgress(ctx context.Context, jobID uuid.UUID, percent int, message string) *common.AppError {
+	// Reports arrive while the handler runs, outside of any transaction; a job
+	// that finished in the meantime keeps its final state
+	query := `UPDATE jobs SET progress = $1, progress_message = $2 WHERE id = $3 AND status = 'processing'`
+	if _, err := jr.db.Exec(ctx, query, percent, message, jobID); err != nil {
+		return common.NewUnexpectedServerError("Failed to save job progress", err)
+	}
+	return nil
+}
+
+// insertDeadJob snapshots a failed job into the dead-letter queue.
+func insertDeadJob(ctx context.Context, tx pgx.Tx, jobID uuid.UUID, lastError string) *common.AppError {
+	query := `
+		INSERT INTO dead_jobs (job_id, user_id, type, payload, attempts, last_error, attempt_history, failed_at)
+		SELECT id, user_id, type, payload, attempts, $2, attempt_history, now()
 		FROM jobs WHERE id = $1
+		ON CONFLICT (job_id) DO UPDATE SET
+			payload = EXCLUDED.payload,
+			attempts = EXCLUDED.attempts,
+			last_error = EXCLUDED.last_error,
+			attempt_history = EXCLUDED.attempt_history,
+			failed_at = EXCLUDED.failed_at
+	`
+	if _, err := tx.Exec(ctx, query, jobID, lastError); err != nil {
+		return common.NewUnexpectedServerError("Failed to move job to the dead-letter queue", err)
+	}
+	return nil
+}
+
+func (jr jobRepository) UpdateJob(ctx context.Context, owner *uuid.UUID, jobID uuid.UUID, payload map[string]any, priority *string, runAt *time.Time) (*domain.Job, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// Lock the row so the scheduler can't dispatch the job while it changes
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM jobs WHERE id = $1 AND `+ownedBy+` FOR UPDATE`, jobID, owner).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Job not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job", err)
+	}
+	if status != "pending" && status != "blocked" {
+		return nil, common.NewDuplicateError("Job is already " + status)
+	}
+
+	// A nil payload must reach Postgres as NULL rather than JSON null
+	var newPayload any
+	if payload != nil {
+		newPayload = payload
+	}
+
+	// The update notification lets the scheduler move the job within its heap
+	query := `
+		UPDATE jobs SET
+			payload = COALESCE($1, payload),
+			priority = COALESCE($2, priority),
+			run_at = COALESCE($3, run_at),
+			updated_at = $4
+		WHERE id = $5
+		RETURNING id, user_id, type, payload, status, priority, attempts, run_at, created_at, updated_at, retry_policy
+	`
+	job := domain.Job{}
+	err = tx.QueryRow(ctx, query, newPayload, priority, runAt, time.Now().In(common.DhakaTZ), jobID).Scan(
+		&job.ID, &job.UserID, &job.Type, &job.Payload, &job.Status, &job.Priority,
+		&job.Attempts, &job.RunAt, &job.CreatedAt, &job.UpdatedAt, &job.RetryPolicy,
+	)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to update job", err)
+	}
+	return &job, nil
+}
+
+func (jr jobRepository) GetJobGraph(ctx context.Context, owner *uuid.UUID, jobID uuid.UUID) (*domain.JobGraphResponseDTO, *common.AppError) {
+	// Walk the edges in both directions until the whole workflow is found
+	query := `
+		WITH RECURSIVE workflow(id) AS (
+			SELECT $1::uuid
+			UNION
+			SELECT CASE WHEN d.job_id = w.id THEN d.depends_on ELSE d.job_id END
+			FROM job_dependencies d
+			JOIN workflow w ON d.job_id = w.id OR d.depends_on = w.id
+		)
+		SELECT j.id, j.type, j.status, j.priority, j.run_at
+		FROM jobs j JOIN workflow w ON w.id = j.id
+		WHERE ($2::uuid IS NULL OR j.user_id = $2)
+		ORDER BY j.created_at
+	`
+	rows, err := jr.conn(ctx).Query(ctx, query, jobID, owner)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job graph", err)
+	}
+	defer rows.Close()
+
+	graph := domain.JobGraphResponseDTO{Nodes: []domain.JobGraphNode{}, Edges: []domain.JobGraphEdge{}}
+	nodeIDs := []uuid.UUID{}
+	found := false
+	for rows.Next() {
+		var node domain.JobGraphNode
+		if err := rows.Scan(&node.ID, &node.Type, &node.Status, &node.Priority, &node.RunAt); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan job graph node", err)
+		}
+		found = found || node.ID == jobID
+		graph.Nodes = append(graph.Nodes, node)
+		nodeIDs = append(nodeIDs, node.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job graph", err)
+	}
+	if !found {
+		return nil, common.NewNotFoundError("Job not found")
+	}
+
+	rows, err = jr.conn(ctx).Query(ctx, `SELECT job_id, depends_on, on_failure FROM job_dependencies WHERE job_id = ANY($1)`, nodeIDs)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job graph", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var edge domain.JobGraphEdge
+		if err := rows.Scan(&edge.JobID, &edge.DependsOn, &edge.OnFailure); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan job graph edge", err)
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job graph", err)
+	}
+	return &graph, nil
+}
+
+// jobFilterConditions returns the WHERE clause selecting a user's jobs
+// matching filter along with its arguments, leaving the page aside.
+func jobFilterConditions(userID uuid.UUID, filter domain.JobFilter) (string, []any) {
+	args := []any{userID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"user_id = $1"}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(filter.Statuses)+")")
+	}
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, "type = ANY("+arg(filter.Types)+")")
+	}
+	if len(filter.Priorities) > 0 {
+		conditions = append(conditions, "priority = ANY("+arg(filter.Priorities)+")")
+	}
+	if filter.RunAtFrom != nil {
+		conditions = append(conditions, "run_at >= "+arg(*filter.RunAtFrom))
+	}
+	if filter.RunAtTo != nil {
+		conditions = append(conditions, "run_at <= "+arg(*filter.RunAtTo))
+	}
+	// created_at has no time zone, it holds the server's local time
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ("+arg(*filter.CreatedFrom)+"::timestamptz AT TIME ZONE current_setting('TimeZone'))")
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at <= ("+arg(*filter.CreatedTo)+"::timestamptz AT TIME ZONE current_setting('TimeZone'))")
+	}
+	for _, match := range filter.Payload {
+		conditions = append(conditions, "payload #>> "+arg(match.Path)+"::text[] = "+arg(match.Value))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func (jr jobRepository) ListJobs(ctx context.Context, userID uuid.UUID, filter domain.JobFilter) ([]domain.Job, *common.AppError) {
+	where, args := jobFilterConditions(userID, filter)
+
+	// Keyset pagination: continue strictly past the cursor in (sort column, id) order
+	column, direction, comparison := "created_at", "ASC", ">"
+	if filter.SortBy == "run_at" {
+		column = "run_at"
+	}
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Value, filter.After.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args))
+	}
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, type, payload, status, priority, attempts, run_at, created_at, updated_at,
+			retry_policy, batch_id, result, COALESCE(last_error, ''), started_at, finished_at
+		FROM jobs
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d
+	`, where, column, direction, direction, len(args))
+	rows, err := jr.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to list jobs", err)
+	}
+	defer rows.Close()
+
+	jobs := []domain.Job{}
+	for rows.Next() {
+		var job domain.Job
+		err := rows.Scan(
+			&job.ID, &job.UserID, &job.Type, &job.Payload, &job.Status,
+			&job.Priority, &job.Attempts, &job.RunAt, &job.CreatedAt, &job.UpdatedAt,
+			&job.RetryPolicy, &job.BatchID, &job.Result, &job.LastError, &job.StartedAt, &job.FinishedAt,
+		)
+		if err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan job", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to list jobs", err)
+	}
+	return jobs, nil
+}
+
+func (jr jobRepository) CountJobs(ctx context.Context, userID uuid.UUID, filter domain.JobFilter) (int, *common.AppError) {
+	where, args := jobFilterConditions(userID, filter)
+
+	var count int
+	if err := jr.conn(ctx).QueryRow(ctx, `SELECT count(*) FROM jobs WHERE `+where, args...).Scan(&count); err != nil {
+		return 0, common.NewUnexpectedServerError("Failed to count jobs", err)
+	}
+	return count, nil
+}
+
+func (jr jobRepository) CountJobsByStatus(ctx context.Context) (map[string]int, *common.AppError) {
+	// The stats are queue wide, so count on the pool: the transaction of the
+	// request would only see the jobs of the operator asking
+	rows, err := jr.db.Query(ctx, `SELECT status, count(*) FROM jobs GROUP BY status`)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to count jobs", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan job count", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to count jobs", err)
+	}
+	return counts, nil
+}
+
+func (jr jobRepository) GetJobEvents(ctx context.Context, owner *uuid.UUID, jobID uuid.UUID) ([]domain.JobEvent, *common.AppError) {
+	var exists bool
+	err := jr.conn(ctx).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM jobs WHERE id = $1 AND `+ownedBy+`)`, jobID, owner).Scan(&exists)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job events", err)
+	}
+	if !exists {
+		return nil, common.NewNotFoundError("Job not found")
+	}
+
+	query := `
+		SELECT id, job_id, event, status, attempt, actor, COALESCE(error, ''), created_at
+		FROM job_events
+		WHERE job_id = $1
+		ORDER BY id
+	`
+	rows, err := jr.conn(ctx).Query(ctx, query, jobID)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job events", err)
+	}
+	defer rows.Close()
+
+	events := []domain.JobEvent{}
+	for rows.Next() {
+		var event domain.JobEvent
+		if err := rows.Scan(&event.ID, &event.JobID, &event.Event, &event.Status, &event.Attempt, &event.Actor, &event.Error, &event.CreatedAt); err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan job event", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve job events", err)
+	}
+	return events, nil
+}
+
+func (jr jobRepository) CancelJob(ctx context.Context, owner *uuid.UUID, jobID uuid.UUID) (string, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// Lock the row so the scheduler or a worker can't move the job on concurrently
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM jobs WHERE id = $1 AND `+ownedBy+` FOR UPDATE`, jobID, owner).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", common.NewNotFoundError("Job not found")
+	}
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Failed to retrieve job", err)
+	}
+
+	switch status {
+	case "completed", "failed", "cancelled", "skipped":
+		return "", common.NewDuplicateError("Job is already " + status)
+	}
+
+	// Dropping the lease keeps a Postgres broker worker from recording an outcome
+	query := `
+		UPDATE jobs SET status = 'cancelled', locked_by = NULL, locked_until = NULL, updated_at = $1
+		WHERE id = $2
+	`
+	if _, err := tx.Exec(ctx, query, time.Now().In(common.DhakaTZ), jobID); err != nil {
+		return "", common.NewUnexpectedServerError("Failed to cancel job", err)
+	}
+	return status, nil
+}
+
+func (jr jobRepository) GetJobStatus(ctx context.Context, owner *uuid.UUID, jobID uuid.UUID) (*domain.JobStatusResponseDTO, *common.AppError) {
+	// Retrieve job status from database
+	query := `
+		SELECT type, status, priority, attempts, run_at, progress, COALESCE(progress_message, ''),
+			result, COALESCE(last_error, ''), started_at, finished_at
+		FROM jobs WHERE id = $1 AND ` + ownedBy + `
 	`
 	job := domain.JobStatusResponseDTO{}
-	err := jr.db.QueryRow(ctx, query, jobID).Scan(&job.Type, &job.Status, &job.Priority, &job.Attempts, &job.RunAt)
+	err := jr.conn(ctx).QueryRow(ctx, query, jobID, owner).Scan(
+		&job.Type, &job.Status, &job.Priority, &job.Attempts, &job.RunAt, &job.Progress, &job.ProgressMessage,
+		&job.Result, &job.LastError, &job.StartedAt, &job.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Job not found")
+	}
 	if err != nil {
 		return nil, common.NewUnexpectedServerError("Failed to retrieve job status", err)
 	}
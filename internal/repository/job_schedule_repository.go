@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobScheduleRepository interface {
+	// CreateSchedule creates a new schedule in the database.
+	CreateSchedule(context.Context, domain.JobSchedule) (*domain.JobSchedule, *common.AppError)
+	// ListSchedules retrieves every schedule of a user.
+	ListSchedules(context.Context, uuid.UUID) ([]domain.JobSchedule, *common.AppError)
+	// GetSchedule retrieves one of a user's schedules.
+	GetSchedule(context.Context, uuid.UUID, uuid.UUID) (*domain.JobSchedule, *common.AppError)
+	// UpdateSchedule overwrites one of a user's schedules.
+	UpdateSchedule(context.Context, domain.JobSchedule) (*domain.JobSchedule, *common.AppError)
+	// DeleteSchedule removes one of a user's schedules; jobs it created are kept.
+	DeleteSchedule(context.Context, uuid.UUID, uuid.UUID) *common.AppError
+	// ClaimDueSchedules locks up to limit enabled schedules whose next run is due.
+	ClaimDueSchedules(context.Context, int) ([]domain.JobSchedule, *common.AppError)
+	// MaterializeSchedule creates the jobs of the given occurrences and moves the schedule to its next run.
+	MaterializeSchedule(context.Context, domain.JobSchedule, []time.Time, time.Time) *common.AppError
+}
+
+type jobScheduleRepository struct {
+	db *pgxpool.Pool
+}
+
+const jobScheduleColumns = `id, user_id, cron_spec, timezone, job_type, payload, priority, catch_up, enabled, next_run_at, last_run_at, created_at, updated_at`
+
+func scanJobSchedule(row pgx.Row) (*domain.JobSchedule, error) {
+	var schedule domain.JobSchedule
+	err := row.Scan(
+		&schedule.ID, &schedule.UserID, &schedule.CronSpec, &schedule.Timezone,
+		&schedule.JobType, &schedule.Payload, &schedule.Priority, &schedule.CatchUp,
+		&schedule.Enabled, &schedule.NextRunAt, &schedule.LastRunAt,
+		&schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func collectJobSchedules(rows pgx.Rows) ([]domain.JobSchedule, error) {
+	defer rows.Close()
+
+	schedules := []domain.JobSchedule{}
+	for rows.Next() {
+		schedule, err := scanJobSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (sr jobScheduleRepository) CreateSchedule(ctx context.Context, schedule domain.JobSchedule) (*domain.JobSchedule, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	query := `
+		INSERT INTO job_schedules (user_id, cron_spec, timezone, job_type, payload, priority, catch_up, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + jobScheduleColumns + `
+	`
+	created, err := scanJobSchedule(tx.QueryRow(ctx, query,
+		schedule.UserID, schedule.CronSpec, schedule.Timezone, schedule.JobType,
+		schedule.Payload, schedule.Priority, schedule.CatchUp, schedule.Enabled, schedule.NextRunAt,
+	))
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to create schedule", err)
+	}
+	return created, nil
+}
+
+func (sr jobScheduleRepository) ListSchedules(ctx context.Context, userID uuid.UUID) ([]domain.JobSchedule, *common.AppError) {
+	query := `SELECT ` + jobScheduleColumns + ` FROM job_schedules WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := sr.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve schedules", err)
+	}
+	schedules, err := collectJobSchedules(rows)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve schedules", err)
+	}
+	return schedules, nil
+}
+
+func (sr jobScheduleRepository) GetSchedule(ctx context.Context, userID uuid.UUID, scheduleID uuid.UUID) (*domain.JobSchedule, *common.AppError) {
+	query := `SELECT ` + jobScheduleColumns + ` FROM job_schedules WHERE id = $1 AND user_id = $2`
+
+	schedule, err := scanJobSchedule(sr.db.QueryRow(ctx, query, scheduleID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Schedule not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve schedule", err)
+	}
+	return schedule, nil
+}
+
+func (sr jobScheduleRepository) UpdateSchedule(ctx context.Context, schedule domain.JobSchedule) (*domain.JobSchedule, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	query := `
+		UPDATE job_schedules SET
+			cron_spec = $1, timezone = $2, job_type = $3, payload = $4, priority = $5,
+			catch_up = $6, enabled = $7, next_run_at = $8, updated_at = now()
+		WHERE id = $9 AND user_id = $10
+		RETURNING ` + jobScheduleColumns + `
+	`
+	updated, err := scanJobSchedule(tx.QueryRow(ctx, query,
+		schedule.CronSpec, schedule.Timezone, schedule.JobType, schedule.Payload, schedule.Priority,
+		schedule.CatchUp, schedule.Enabled, schedule.NextRunAt, schedule.ID, schedule.UserID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("Schedule not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to update schedule", err)
+	}
+	return updated, nil
+}
+
+func (sr jobScheduleRepository) DeleteSchedule(ctx context.Context, userID uuid.UUID, scheduleID uuid.UUID) *common.AppError {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM job_schedules WHERE id = $1 AND user_id = $2`, scheduleID, userID)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to delete schedule", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return common.NewNotFoundError("Schedule not found")
+	}
+	return nil
+}
+
+func (sr jobScheduleRepository) ClaimDueSchedules(ctx context.Context, limit int) ([]domain.JobSchedule, *common.AppError) {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// SKIP LOCKED lets every API replica fire schedules without firing one twice
+	query := `
+		SELECT ` + jobScheduleColumns + ` FROM job_schedules
+		WHERE enabled AND next_run_at <= now()
+		ORDER BY next_run_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to claim due schedules", err)
+	}
+	schedules, err := collectJobSchedules(rows)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to claim due schedules", err)
+	}
+	return schedules, nil
+}
+
+func (sr jobScheduleRepository) MaterializeSchedule(ctx context.Context, schedule domain.JobSchedule, runAts []time.Time, nextRunAt time.Time) *common.AppError {
+	// Extract transaction from context
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	if len(runAts) > 0 {
+		query := `
+			INSERT INTO jobs (user_id, type, payload, status, priority, attempts, run_at, schedule_id)
+			SELECT $1, $2, $3, 'pending', $4, 0, run_at, $5
+			FROM unnest($6::timestamptz[]) AS run_at
+			ON CONFLICT (schedule_id, run_at) DO NOTHING
+		`
+		_, err := tx.Exec(ctx, query,
+			schedule.UserID, schedule.JobType, schedule.Payload, schedule.Priority, schedule.ID, runAts,
+		)
+		if err != nil {
+			return common.NewUnexpectedServerError("Failed to create scheduled jobs", err)
+		}
+	}
+
+	var lastRunAt *time.Time
+	if len(runAts) > 0 {
+		lastRunAt = &runAts[len(runAts)-1]
+	}
+	// A zero next run means the cron spec has no further occurrence
+	query := `
+		UPDATE job_schedules SET next_run_at = $1, last_run_at = COALESCE($2, last_run_at), enabled = $3, updated_at = now()
+		WHERE id = $4
+	`
+	if _, err := tx.Exec(ctx, query, nextRunAt, lastRunAt, !nextRunAt.IsZero(), schedule.ID); err != nil {
+		return common.NewUnexpectedServerError("Failed to advance schedule", err)
+	}
+	return nil
+}
+
+func NewJobScheduleRepository(db *pgxpool.Pool) jobScheduleRepository {
+	return jobScheduleRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateLimitRepository interface {
+	// SaveRateLimitState replaces the saved state of the dispatch token buckets.
+	SaveRateLimitState(context.Context, ratelimit.State, time.Time) *common.AppError
+	// GetRateLimitState retrieves the saved state and when it was taken, or nil if none was saved.
+	GetRateLimitState(context.Context) (*ratelimit.State, time.Time, *common.AppError)
+}
+
+type rateLimitRepository struct {
+	db *pgxpool.Pool
+}
+
+func (rr rateLimitRepository) SaveRateLimitState(ctx context.Context, state ratelimit.State, takenAt time.Time) *common.AppError {
+	query := `
+		INSERT INTO rate_limit_state (state, taken_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET state = EXCLUDED.state, taken_at = EXCLUDED.taken_at
+	`
+	if _, err := rr.db.Exec(ctx, query, state, takenAt); err != nil {
+		return common.NewUnexpectedServerError("Failed to save rate limits", err)
+	}
+	return nil
+}
+
+func (rr rateLimitRepository) GetRateLimitState(ctx context.Context) (*ratelimit.State, time.Time, *common.AppError) {
+	var state ratelimit.State
+	var takenAt time.Time
+	err := rr.db.QueryRow(ctx, `SELECT state, taken_at FROM rate_limit_state`).Scan(&state, &takenAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, common.NewUnexpectedServerError("Failed to retrieve rate limits", err)
+	}
+	return &state, takenAt, nil
+}
+
+func NewRateLimitRepository(db *pgxpool.Pool) rateLimitRepository {
+	return rateLimitRepository{
+		db: db,
+	}
+}
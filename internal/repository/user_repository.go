@@ -2,20 +2,40 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Nezent/go-queue/common"
 	"github.com/Nezent/go-queue/internal/domain"
 	"github.com/Nezent/go-queue/internal/middleware"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserRepository interface {
 	RegisterUser(context.Context, domain.User) (*domain.User, *common.AppError)
-	LoginUser(context.Context, string, string) (*uuid.UUID, *common.AppError)
+	// LoginUser checks the credentials of a user and returns their ID and role.
+	LoginUser(context.Context, string, string) (*domain.User, *common.AppError)
 	VerifyUser(context.Context, string) *common.AppError
+	// GetWebhookSecret retrieves the key the user's http:request jobs are signed with.
+	GetWebhookSecret(context.Context, uuid.UUID) (string, *common.AppError)
+	// RotateWebhookSecret replaces the user's webhook secret with a random one and returns it.
+	RotateWebhookSecret(context.Context, uuid.UUID) (string, *common.AppError)
+	// ListUsers retrieves up to limit users, oldest first.
+	ListUsers(context.Context, int) ([]domain.UserSummaryDTO, *common.AppError)
+	// UpdateUserRole changes the role of a user.
+	UpdateUserRole(context.Context, uuid.UUID, string) (*domain.UserSummaryDTO, *common.AppError)
+	// CreateAdmin creates a verified admin, or promotes the user with the same email.
+	CreateAdmin(context.Context, domain.User) (*domain.UserSummaryDTO, *common.AppError)
+	// CreateRefreshToken stores the hash of a user's refresh token, starting a new family.
+	CreateRefreshToken(context.Context, uuid.UUID, string, time.Time) *common.AppError
+	// RotateRefreshToken exchanges the token with the first hash for one with the
+	// second and returns the user's ID and role. Presenting a rotated token revokes its family.
+	RotateRefreshToken(context.Context, string, string, time.Time) (*domain.User, *common.AppError)
+	// RevokeRefreshTokenFamily revokes the token with the hash and every token of its family.
+	RevokeRefreshTokenFamily(context.Context, string) *common.AppError
 }
 
 type userRepository struct {
@@ -67,19 +87,19 @@ func (ur userRepository) RegisterUser(ctx context.Context, user domain.User) (*d
 	return &user, nil
 }
 
-func (ur userRepository) LoginUser(ctx context.Context, email, password string) (*uuid.UUID, *common.AppError) {
-	var userID uuid.UUID
+func (ur userRepository) LoginUser(ctx context.Context, email, password string) (*domain.User, *common.AppError) {
+	var user domain.User
 	var passwordHash string
 
-	query := `SELECT id, password_hash FROM users WHERE email = $1`
-	err := ur.db.QueryRow(ctx, query, email).Scan(&userID, &passwordHash)
+	query := `SELECT id, password_hash, role FROM users WHERE email = $1`
+	err := ur.db.QueryRow(ctx, query, email).Scan(&user.ID, &passwordHash, &user.Role)
 	if err != nil {
 		return nil, common.NewUnauthorizedError("Invalid credentials")
 	}
 	if err := common.CompareHashPassword(passwordHash, password); err != nil {
 		return nil, common.NewUnauthorizedError("Invalid credentials")
 	}
-	return &userID, nil
+	return &user, nil
 }
 
 func (r userRepository) VerifyUser(ctx context.Context, token string) *common.AppError {
@@ -102,6 +122,197 @@ func (r userRepository) VerifyUser(ctx context.Context, token string) *common.Ap
 	return nil
 }
 
+func (ur userRepository) GetWebhookSecret(ctx context.Context, userID uuid.UUID) (string, *common.AppError) {
+	// Read outside of a transaction, workers sign requests with it
+	var secret string
+	err := ur.db.QueryRow(ctx, `SELECT webhook_secret FROM users WHERE id = $1`, userID).Scan(&secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", common.NewNotFoundError("User not found")
+	}
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Failed to retrieve webhook secret", err)
+	}
+	return secret, nil
+}
+
+func (ur userRepository) RotateWebhookSecret(ctx context.Context, userID uuid.UUID) (string, *common.AppError) {
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	var secret string
+	query := `UPDATE users SET webhook_secret = encode(gen_random_bytes(32), 'hex') WHERE id = $1 RETURNING webhook_secret`
+	err = tx.QueryRow(ctx, query, userID).Scan(&secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", common.NewNotFoundError("User not found")
+	}
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Failed to rotate webhook secret", err)
+	}
+	return secret, nil
+}
+
+const userSummaryColumns = `id, name, email, COALESCE(email_verified, FALSE), role, last_login_at`
+
+func scanUserSummary(row pgx.Row) (*domain.UserSummaryDTO, error) {
+	var user domain.UserSummaryDTO
+	if err := row.Scan(&user.ID, &user.Name, &user.Email, &user.EmailVerified, &user.Role, &user.LastLoginAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (ur userRepository) ListUsers(ctx context.Context, limit int) ([]domain.UserSummaryDTO, *common.AppError) {
+	rows, err := ur.db.Query(ctx, `SELECT `+userSummaryColumns+` FROM users ORDER BY created_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve users", err)
+	}
+	defer rows.Close()
+
+	users := []domain.UserSummaryDTO{}
+	for rows.Next() {
+		user, err := scanUserSummary(rows)
+		if err != nil {
+			return nil, common.NewUnexpectedServerError("Failed to scan user", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve users", err)
+	}
+	return users, nil
+}
+
+func (ur userRepository) UpdateUserRole(ctx context.Context, userID uuid.UUID, role string) (*domain.UserSummaryDTO, *common.AppError) {
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	user, err := scanUserSummary(tx.QueryRow(ctx, `UPDATE users SET role = $1 WHERE id = $2 RETURNING `+userSummaryColumns, role, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewNotFoundError("User not found")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to update user role", err)
+	}
+	return user, nil
+}
+
+func (ur userRepository) CreateAdmin(ctx context.Context, user domain.User) (*domain.UserSummaryDTO, *common.AppError) {
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	hashedPassword, err := common.GenerateHashPassword(user.Password)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to hash password", err)
+	}
+
+	// An existing account keeps its password and only gains the role
+	query := `
+		INSERT INTO users (name, email, password_hash, email_verified, role, created_at)
+		VALUES ($1, $2, $3, TRUE, 'admin', $4)
+		ON CONFLICT (email) DO UPDATE SET role = 'admin'
+		RETURNING ` + userSummaryColumns
+	admin, err := scanUserSummary(tx.QueryRow(ctx, query, user.Name, user.Email, hashedPassword, time.Now().In(common.DhakaTZ)))
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to create admin", err)
+	}
+	return admin, nil
+}
+
+func (ur userRepository) CreateRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) *common.AppError {
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, userID, uuid.New(), tokenHash, expiresAt); err != nil {
+		return common.NewUnexpectedServerError("Failed to store refresh token", err)
+	}
+	return nil
+}
+
+func (ur userRepository) RotateRefreshToken(ctx context.Context, tokenHash string, newTokenHash string, expiresAt time.Time) (*domain.User, *common.AppError) {
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// Lock the token so concurrent refreshes with it can't both succeed
+	var tokenID, familyID uuid.UUID
+	var tokenExpiresAt time.Time
+	var rotatedAt, revokedAt *time.Time
+	user := domain.User{}
+	query := `
+		SELECT t.id, t.family_id, t.expires_at, t.rotated_at, t.revoked_at, u.id, u.role
+		FROM refresh_tokens t JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t
+	`
+	err = tx.QueryRow(ctx, query, tokenHash).Scan(&tokenID, &familyID, &tokenExpiresAt, &rotatedAt, &revokedAt, &user.ID, &user.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewUnauthorizedError("Invalid refresh token")
+	}
+	if err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to retrieve refresh token", err)
+	}
+
+	switch {
+	case revokedAt != nil:
+		return nil, common.NewUnauthorizedError("Refresh token revoked")
+	case rotatedAt != nil:
+		// Only a copy of the token can be presented twice: whoever holds the
+		// latest one may be an attacker, so end the whole session. The request
+		// transaction commits the revocation along with the error response.
+		if appErr := revokeRefreshTokenFamily(ctx, tx, familyID); appErr != nil {
+			return nil, appErr
+		}
+		return nil, common.NewUnauthorizedError("Refresh token reuse detected, the session was revoked")
+	case !tokenExpiresAt.After(time.Now()):
+		return nil, common.NewUnauthorizedError("Refresh token expired")
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1`, tokenID); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to rotate refresh token", err)
+	}
+	query = `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, user.ID, familyID, newTokenHash, expiresAt); err != nil {
+		return nil, common.NewUnexpectedServerError("Failed to rotate refresh token", err)
+	}
+	return &user, nil
+}
+
+func (ur userRepository) RevokeRefreshTokenFamily(ctx context.Context, tokenHash string) *common.AppError {
+	tx, err := middleware.GetTxFromContext(ctx)
+	if err != nil {
+		return common.NewUnexpectedServerError("Transaction context not found", err)
+	}
+
+	// Unknown tokens have nothing to revoke
+	var familyID uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT family_id FROM refresh_tokens WHERE token_hash = $1`, tokenHash).Scan(&familyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to retrieve refresh token", err)
+	}
+	return revokeRefreshTokenFamily(ctx, tx, familyID)
+}
+
+func revokeRefreshTokenFamily(ctx context.Context, tx pgx.Tx, familyID uuid.UUID) *common.AppError {
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, query, familyID); err != nil {
+		return common.NewUnexpectedServerError("Failed to revoke refresh tokens", err)
+	}
+	return nil
+}
+
 func NewUserRepository(db *pgxpool.Pool) userRepository {
 	return userRepository{db: db}
 }
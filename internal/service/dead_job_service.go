@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/Nezent/go-queue/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeadJobLimit = 50
+	maxDeadJobLimit     = 500
+)
+
+type DeadJobService interface {
+	// ListDeadJobs retrieves the current user's dead jobs.
+	ListDeadJobs(context.Context, int) ([]domain.DeadJob, *common.AppError)
+	// GetDeadJob retrieves one of the current user's dead jobs.
+	GetDeadJob(context.Context, uuid.UUID) (*domain.DeadJob, *common.AppError)
+	// RequeueDeadJobs resets dead jobs so the scheduler runs them again.
+	RequeueDeadJobs(context.Context, []uuid.UUID) (*domain.DeadJobsAffectedResponseDTO, *common.AppError)
+	// PurgeDeadJobs removes dead jobs, or all of them if no IDs are given.
+	PurgeDeadJobs(context.Context, []uuid.UUID) (*domain.DeadJobsAffectedResponseDTO, *common.AppError)
+}
+
+type deadJobService struct {
+	repo repository.DeadJobRepository
+}
+
+func NewDeadJobService(repo repository.DeadJobRepository) DeadJobService {
+	return &deadJobService{repo: repo}
+}
+
+func (ds *deadJobService) ListDeadJobs(ctx context.Context, limit int) ([]domain.DeadJob, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if limit <= 0 {
+		limit = defaultDeadJobLimit
+	}
+	limit = min(limit, maxDeadJobLimit)
+
+	return ds.repo.ListDeadJobs(ctx, userID, limit)
+}
+
+func (ds *deadJobService) GetDeadJob(ctx context.Context, jobID uuid.UUID) (*domain.DeadJob, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return ds.repo.GetDeadJob(ctx, userID, jobID)
+}
+
+func (ds *deadJobService) RequeueDeadJobs(ctx context.Context, jobIDs []uuid.UUID) (*domain.DeadJobsAffectedResponseDTO, *common.AppError) {
+	if len(jobIDs) == 0 {
+		return nil, common.NewBadRequestError("At least one job ID is required")
+	}
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	requeued, appErr := ds.repo.RequeueDeadJobs(ctx, userID, jobIDs)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &domain.DeadJobsAffectedResponseDTO{JobIDs: requeued, Count: len(requeued)}, nil
+}
+
+func (ds *deadJobService) PurgeDeadJobs(ctx context.Context, jobIDs []uuid.UUID) (*domain.DeadJobsAffectedResponseDTO, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	purged, appErr := ds.repo.PurgeDeadJobs(ctx, userID, jobIDs)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &domain.DeadJobsAffectedResponseDTO{JobIDs: purged, Count: len(purged)}, nil
+}
+
+// currentUserID returns the authenticated user's ID from the request context.
+func currentUserID(ctx context.Context) (uuid.UUID, *common.AppError) {
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return uuid.Nil, common.NewUnauthorizedError("User ID not found in context")
+	}
+
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, common.NewBadRequestError("Invalid User ID format")
+	}
+	return parsedUserID, nil
+}
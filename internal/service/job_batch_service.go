@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/repository"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/google/uuid"
+)
+
+// maxBatchSize bounds the jobs created by a single batch request.
+const maxBatchSize = 1000
+
+type JobBatchService interface {
+	// CreateBatch creates every job of a batch for the current user.
+	CreateBatch(context.Context, domain.JobBatchCreateRequestDTO) (*domain.JobBatchCreateResponseDTO, *common.AppError)
+	// GetBatch retrieves one of the current user's batches with its counts.
+	GetBatch(context.Context, uuid.UUID) (*domain.JobBatch, *common.AppError)
+	// GetBatchProgress retrieves a batch with its counts.
+	GetBatchProgress(context.Context, uuid.UUID) (*domain.JobBatch, *common.AppError)
+}
+
+type jobBatchService struct {
+	jobRepo   repository.JobRepository
+	batchRepo repository.JobBatchRepository
+	types     *jobtype.Registry
+}
+
+func NewJobBatchService(jobRepo repository.JobRepository, batchRepo repository.JobBatchRepository, types *jobtype.Registry) JobBatchService {
+	return &jobBatchService{jobRepo: jobRepo, batchRepo: batchRepo, types: types}
+}
+
+func (bs *jobBatchService) CreateBatch(ctx context.Context, req domain.JobBatchCreateRequestDTO) (*domain.JobBatchCreateResponseDTO, *common.AppError) {
+	if len(req.Jobs) == 0 {
+		return nil, common.NewBadRequestError("A batch needs at least one job")
+	}
+	if len(req.Jobs) > maxBatchSize {
+		return nil, common.NewBadRequestError("A batch can't have more than 1000 jobs")
+	}
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// Validate everything up front, the request transaction is committed even if we fail halfway
+	jobs := make([]*domain.Job, len(req.Jobs))
+	dependsOn := []uuid.UUID{}
+	for i, jobDTO := range req.Jobs {
+		// A deduplicated job would never count towards the batch
+		if jobDTO.UniqueKey != "" {
+			return nil, common.NewBadRequestError("Jobs of a batch can't declare a unique key")
+		}
+		job, appErr := newJob(bs.types, userID, jobDTO)
+		if appErr != nil {
+			return nil, appErr
+		}
+		jobs[i] = job
+		for _, dependency := range job.DependsOn {
+			if !slices.Contains(dependsOn, dependency.JobID) {
+				dependsOn = append(dependsOn, dependency.JobID)
+			}
+		}
+	}
+	// A missing parent would only be noticed after the batch was half created
+	if appErr := bs.jobRepo.CheckJobDependencies(ctx, userID, dependsOn); appErr != nil {
+		return nil, appErr
+	}
+
+	batch := domain.JobBatch{UserID: userID, Total: len(jobs)}
+	if req.OnComplete != nil {
+		if len(req.OnComplete.DependsOn) > 0 {
+			return nil, common.NewBadRequestError("The on_complete job can't declare dependencies")
+		}
+		onComplete, appErr := newJob(bs.types, userID, *req.OnComplete)
+		if appErr != nil {
+			return nil, appErr
+		}
+
+		// Released by the database once every job of the batch finished
+		onComplete.Status = "blocked"
+		createdJob, appErr := bs.jobRepo.CreateJob(ctx, *onComplete)
+		if appErr != nil {
+			return nil, appErr
+		}
+		batch.OnCompleteJobID = &createdJob.ID
+	}
+
+	createdBatch, appErr := bs.batchRepo.CreateBatch(ctx, batch)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	jobIDs := make([]uuid.UUID, 0, len(jobs))
+	for _, job := range jobs {
+		job.BatchID = &createdBatch.ID
+		createdJob, appErr := bs.jobRepo.CreateJob(ctx, *job)
+		if appErr != nil {
+			return nil, appErr
+		}
+		jobIDs = append(jobIDs, createdJob.ID)
+	}
+
+	return &domain.JobBatchCreateResponseDTO{Batch: *createdBatch, JobIDs: jobIDs}, nil
+}
+
+func (bs *jobBatchService) GetBatch(ctx context.Context, batchID uuid.UUID) (*domain.JobBatch, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return bs.batchRepo.GetBatch(ctx, userID, batchID)
+}
+
+func (bs *jobBatchService) GetBatchProgress(ctx context.Context, batchID uuid.UUID) (*domain.JobBatch, *common.AppError) {
+	// Retrieve batch counts from the repository
+	return bs.batchRepo.GetBatchProgress(ctx, batchID)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/repository"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/priority"
+	"github.com/Nezent/go-queue/internal/worker/schedule"
+	"github.com/google/uuid"
+)
+
+type JobScheduleService interface {
+	// CreateSchedule creates a schedule for the current user.
+	CreateSchedule(context.Context, domain.JobScheduleCreateRequestDTO) (*domain.JobSchedule, *common.AppError)
+	// ListSchedules retrieves the current user's schedules.
+	ListSchedules(context.Context) ([]domain.JobSchedule, *common.AppError)
+	// GetSchedule retrieves one of the current user's schedules.
+	GetSchedule(context.Context, uuid.UUID) (*domain.JobSchedule, *common.AppError)
+	// UpdateSchedule changes one of the current user's schedules.
+	UpdateSchedule(context.Context, uuid.UUID, domain.JobScheduleUpdateRequestDTO) (*domain.JobSchedule, *common.AppError)
+	// DeleteSchedule removes one of the current user's schedules.
+	DeleteSchedule(context.Context, uuid.UUID) *common.AppError
+	// ClaimDueSchedules locks the schedules whose next run is due.
+	ClaimDueSchedules(context.Context, int) ([]domain.JobSchedule, *common.AppError)
+	// MaterializeSchedule creates the jobs of due occurrences and advances the schedule.
+	MaterializeSchedule(context.Context, domain.JobSchedule, []time.Time, time.Time) *common.AppError
+}
+
+type jobScheduleService struct {
+	repo  repository.JobScheduleRepository
+	types *jobtype.Registry
+}
+
+func NewJobScheduleService(repo repository.JobScheduleRepository, types *jobtype.Registry) JobScheduleService {
+	return &jobScheduleService{repo: repo, types: types}
+}
+
+func (ss *jobScheduleService) CreateSchedule(ctx context.Context, req domain.JobScheduleCreateRequestDTO) (*domain.JobSchedule, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	jobSchedule := domain.JobSchedule{
+		UserID:   userID,
+		CronSpec: req.CronSpec,
+		Timezone: req.Timezone,
+		JobType:  req.JobType,
+		Payload:  req.Payload,
+		Priority: req.Priority,
+		CatchUp:  req.CatchUp,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	if appErr := prepareSchedule(ss.types, &jobSchedule, time.Now()); appErr != nil {
+		return nil, appErr
+	}
+
+	return ss.repo.CreateSchedule(ctx, jobSchedule)
+}
+
+func (ss *jobScheduleService) ListSchedules(ctx context.Context) ([]domain.JobSchedule, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return ss.repo.ListSchedules(ctx, userID)
+}
+
+func (ss *jobScheduleService) GetSchedule(ctx context.Context, scheduleID uuid.UUID) (*domain.JobSchedule, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return ss.repo.GetSchedule(ctx, userID, scheduleID)
+}
+
+func (ss *jobScheduleService) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, req domain.JobScheduleUpdateRequestDTO) (*domain.JobSchedule, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	jobSchedule, appErr := ss.repo.GetSchedule(ctx, userID, scheduleID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// The next run is only recomputed when the timing changes, so an edit doesn't skip a due occurrence
+	retime := false
+	if req.CronSpec != nil {
+		jobSchedule.CronSpec = *req.CronSpec
+		retime = true
+	}
+	if req.Timezone != nil {
+		jobSchedule.Timezone = *req.Timezone
+		retime = true
+	}
+	if req.Enabled != nil {
+		retime = retime || (*req.Enabled && !jobSchedule.Enabled)
+		jobSchedule.Enabled = *req.Enabled
+	}
+	if req.JobType != nil {
+		jobSchedule.JobType = *req.JobType
+	}
+	if req.Payload != nil {
+		jobSchedule.Payload = req.Payload
+	}
+	if req.Priority != nil {
+		jobSchedule.Priority = *req.Priority
+	}
+	if req.CatchUp != nil {
+		jobSchedule.CatchUp = *req.CatchUp
+	}
+
+	nextRunAt := jobSchedule.NextRunAt
+	if appErr := prepareSchedule(ss.types, jobSchedule, time.Now()); appErr != nil {
+		return nil, appErr
+	}
+	if !retime {
+		jobSchedule.NextRunAt = nextRunAt
+	}
+
+	return ss.repo.UpdateSchedule(ctx, *jobSchedule)
+}
+
+func (ss *jobScheduleService) DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) *common.AppError {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return appErr
+	}
+
+	return ss.repo.DeleteSchedule(ctx, userID, scheduleID)
+}
+
+func (ss *jobScheduleService) ClaimDueSchedules(ctx context.Context, limit int) ([]domain.JobSchedule, *common.AppError) {
+	// Lock due schedules in the repository
+	return ss.repo.ClaimDueSchedules(ctx, limit)
+}
+
+func (ss *jobScheduleService) MaterializeSchedule(ctx context.Context, jobSchedule domain.JobSchedule, runAts []time.Time, nextRunAt time.Time) *common.AppError {
+	// Create the scheduled jobs in the repository
+	return ss.repo.MaterializeSchedule(ctx, jobSchedule, runAts, nextRunAt)
+}
+
+// prepareSchedule fills in defaults, validates the schedule and computes its
+// next run after now.
+func prepareSchedule(types *jobtype.Registry, jobSchedule *domain.JobSchedule, now time.Time) *common.AppError {
+	if jobSchedule.JobType == "" {
+		return common.NewBadRequestError("Job type is required")
+	}
+	if jobSchedule.Payload == nil {
+		jobSchedule.Payload = map[string]any{}
+	}
+	// Every job the schedule creates carries this type and payload
+	jobType, appErr := checkJobType(types, jobSchedule.JobType, jobSchedule.Payload)
+	if appErr != nil {
+		return appErr
+	}
+
+	if jobSchedule.Timezone == "" {
+		jobSchedule.Timezone = common.DhakaTZ.String()
+	}
+	if jobSchedule.Priority == "" {
+		jobSchedule.Priority = jobType.Priority
+	}
+	if jobSchedule.CatchUp == "" {
+		jobSchedule.CatchUp = schedule.CatchUpSkip
+	}
+
+	if !priority.Valid(jobSchedule.Priority) {
+		return common.NewBadRequestError("Invalid priority")
+	}
+	if !schedule.ValidCatchUp(jobSchedule.CatchUp) {
+		return common.NewBadRequestError("Invalid catch-up policy, expected skip, once or all")
+	}
+
+	spec, err := schedule.Parse(jobSchedule.CronSpec, jobSchedule.Timezone)
+	if err != nil {
+		return common.NewBadRequestError(err.Error())
+	}
+	jobSchedule.NextRunAt = spec.Next(now)
+	if jobSchedule.NextRunAt.IsZero() {
+		return common.NewBadRequestError("Cron spec never fires")
+	}
+	return nil
+}
@@ -2,12 +2,20 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Nezent/go-queue/common"
 	"github.com/Nezent/go-queue/internal/domain"
 	"github.com/Nezent/go-queue/internal/middleware"
 	"github.com/Nezent/go-queue/internal/repository"
+	"github.com/Nezent/go-queue/internal/worker/enqueue"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/priority"
 	"github.com/Nezent/go-queue/internal/worker/task"
 	"github.com/google/uuid"
 )
@@ -17,22 +25,52 @@ type JobService interface {
 	CreateJob(context.Context, domain.JobCreateRequestDTO) (*domain.Job, *common.AppError)
 	// GetJobPayload retrieves a job payload by its ID.
 	GetJobPayload(context.Context, uuid.UUID) (*task.JobPayload, *common.AppError)
+	// GetRecoverableJobs retrieves every job that still has to be scheduled.
+	GetRecoverableJobs(context.Context) ([]task.JobPayload, *common.AppError)
+	// ClaimJobs leases due jobs to a Postgres broker worker.
+	ClaimJobs(context.Context, string, time.Duration, int) ([]task.JobPayload, *common.AppError)
+	// RenewJobLease extends the lease of a job a Postgres broker worker runs.
+	RenewJobLease(context.Context, uuid.UUID, string, time.Duration) *common.AppError
+	// FinishClaimedJob releases a leased job with its outcome and result.
+	FinishClaimedJob(context.Context, uuid.UUID, string, string, time.Time, string, json.RawMessage) *common.AppError
+	// SaveJobResult stores what a job's handler produced.
+	SaveJobResult(context.Context, uuid.UUID, json.RawMessage) *common.AppError
+	// SaveJobProgress stores the progress a running job's handler reported.
+	SaveJobProgress(context.Context, uuid.UUID, int, string) *common.AppError
 	// UpdateJobStatus updates an existing job status in the database.
 	UpdateJobStatus(context.Context, uuid.UUID, string, int) (*domain.Job, *common.AppError)
+	// QueueJob marks a job as queued for dispatch and returns its current payload.
+	QueueJob(context.Context, uuid.UUID, int) (json.RawMessage, *common.AppError)
+	// RetryJob schedules the next attempt of a failed job.
+	RetryJob(context.Context, uuid.UUID, int, time.Time, string) *common.AppError
+	// FailJob marks a job as failed and moves it to the dead-letter queue.
+	FailJob(context.Context, uuid.UUID, int, string) *common.AppError
+	// UpdateJob reschedules, reprioritises or edits one of the current user's pending jobs.
+	UpdateJob(context.Context, uuid.UUID, domain.JobUpdateRequestDTO) (*domain.Job, *common.AppError)
+	// GetJobGraph retrieves the workflow one of the current user's jobs belongs to.
+	GetJobGraph(context.Context, uuid.UUID) (*domain.JobGraphResponseDTO, *common.AppError)
+	// ListJobs retrieves a page of the current user's jobs.
+	ListJobs(context.Context, domain.JobListRequestDTO) (*domain.JobListResponseDTO, *common.AppError)
+	// GetQueueStats counts the jobs of every user by status.
+	GetQueueStats(context.Context) (*domain.QueueStatsResponseDTO, *common.AppError)
+	// GetJobEvents retrieves the timeline of one of the current user's jobs.
+	GetJobEvents(context.Context, uuid.UUID) ([]domain.JobEvent, *common.AppError)
+	// CancelJob cancels one of the current user's jobs.
+	CancelJob(context.Context, uuid.UUID) *common.AppError
 	// GetJobStatus retrieves the status of a job by its ID.
 	GetJobStatus(context.Context, uuid.UUID) (*domain.JobStatusResponseDTO, *common.AppError)
 }
 
 type jobService struct {
-	jobRepo repository.JobRepository
+	jobRepo    repository.JobRepository
+	dispatcher enqueue.Dispatcher
+	types      *jobtype.Registry
+	// priorityAging is the scheduler's aging interval, see priority.Effective
+	priorityAging time.Duration
 }
 
 func (js *jobService) CreateJob(ctx context.Context, job domain.JobCreateRequestDTO) (*domain.Job, *common.AppError) {
 
-	// Validate the job type
-	if job.Type == "" {
-		return nil, common.NewBadRequestError("Job type is required")
-	}
 	userID, ok := middleware.GetUserID(ctx)
 	if !ok {
 		return nil, common.NewUnauthorizedError("User ID not found in context")
@@ -43,36 +81,259 @@ func (js *jobService) CreateJob(ctx context.Context, job domain.JobCreateRequest
 		return nil, common.NewBadRequestError("Invalid User ID format")
 	}
 
+	jobEntity, appErr := newJob(js.types, parsedUserID, job)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	createdJob, appErr := js.jobRepo.CreateJob(ctx, *jobEntity)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return createdJob, nil
+}
+
+// newJob validates a job creation request of the user.
+func newJob(types *jobtype.Registry, userID uuid.UUID, job domain.JobCreateRequestDTO) (*domain.Job, *common.AppError) {
+	// Validate the job type and its payload
+	if job.Type == "" {
+		return nil, common.NewBadRequestError("Job type is required")
+	}
+	jobType, appErr := checkJobType(types, job.Type, job.Payload)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if job.Priority == "" {
+		job.Priority = jobType.Priority
+	}
+	if !priority.Valid(job.Priority) {
+		return nil, common.NewBadRequestError("Invalid priority")
+	}
+
 	timeParse, err := time.ParseInLocation("2006-01-02T15:04:05", job.RunAt, common.DhakaTZ)
 	if err != nil {
 		return nil, common.NewBadRequestError("Invalid RunAt format")
 	}
 
-	jobEntity := domain.Job{
-		UserID:   parsedUserID,
-		Type:     job.Type,
-		Payload:  job.Payload,
-		Priority: job.Priority,
-		RunAt:    timeParse,
+	if job.Retry != nil {
+		if msg := job.Retry.Validate(); msg != "" {
+			return nil, common.NewBadRequestError("Invalid retry policy: " + msg)
+		}
 	}
 
-	createdJob, appErr := js.jobRepo.CreateJob(ctx, jobEntity)
+	dependsOn, appErr := normalizeDependencies(job.DependsOn)
 	if appErr != nil {
 		return nil, appErr
 	}
 
-	return createdJob, nil
+	if len(job.IdempotencyKey) > maxJobKeyLength || len(job.UniqueKey) > maxJobKeyLength {
+		return nil, common.NewBadRequestError("Idempotency and unique keys can't be longer than 255 characters")
+	}
+	if job.UniqueWindow < 0 {
+		return nil, common.NewBadRequestError("Unique window can't be negative")
+	}
+
+	return &domain.Job{
+		UserID:      userID,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		Priority:    job.Priority,
+		RunAt:       timeParse,
+		RetryPolicy: job.Retry,
+		DependsOn:   dependsOn,
+
+		IdempotencyKey: job.IdempotencyKey,
+		UniqueKey:      job.UniqueKey,
+		UniqueWindow:   job.UniqueWindow,
+	}, nil
+}
+
+// checkJobType returns the registered job type called name after validating
+// payload against it. Internal types can't be created by users.
+func checkJobType(types *jobtype.Registry, name string, payload map[string]any) (jobtype.Type, *common.AppError) {
+	jobType, ok := types.Lookup(name)
+	if !ok || jobType.Internal {
+		return jobtype.Type{}, common.NewBadRequestError("Unknown job type " + name)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return jobtype.Type{}, common.NewBadRequestError("Invalid payload")
+	}
+	if err := jobType.Decode(data); err != nil {
+		return jobtype.Type{}, common.NewBadRequestError("Invalid payload: " + err.Error())
+	}
+	return jobType, nil
+}
+
+// jobOwner returns the user whose jobs the current request may access, or nil
+// if the user's role may access the jobs of every user.
+func jobOwner(ctx context.Context) (*uuid.UUID, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role, _ := middleware.GetUserRole(ctx); middleware.HasPermission(role, middleware.PermissionAllJobs) {
+		return nil, nil
+	}
+	return &userID, nil
+}
+
+func (js *jobService) UpdateJob(ctx context.Context, jobID uuid.UUID, job domain.JobUpdateRequestDTO) (*domain.Job, *common.AppError) {
+	if job.Payload == nil && job.Priority == nil && job.RunAt == nil {
+		return nil, common.NewBadRequestError("Nothing to update")
+	}
+	owner, appErr := jobOwner(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if job.Priority != nil && !priority.Valid(*job.Priority) {
+		return nil, common.NewBadRequestError("Invalid priority")
+	}
+	if job.Payload != nil {
+		// The new payload has to suit the type of the job
+		current, appErr := js.jobRepo.GetJobStatus(ctx, owner, jobID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if current == nil {
+			return nil, common.NewNotFoundError("Job not found")
+		}
+		if _, appErr := checkJobType(js.types, current.Type, job.Payload); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	var runAt *time.Time
+	if job.RunAt != nil {
+		timeParse, err := time.ParseInLocation("2006-01-02T15:04:05", *job.RunAt, common.DhakaTZ)
+		if err != nil {
+			return nil, common.NewBadRequestError("Invalid RunAt format")
+		}
+		runAt = &timeParse
+	}
+
+	return js.jobRepo.UpdateJob(ctx, owner, jobID, job.Payload, job.Priority, runAt)
+}
+
+func (js *jobService) GetJobGraph(ctx context.Context, jobID uuid.UUID) (*domain.JobGraphResponseDTO, *common.AppError) {
+	owner, appErr := jobOwner(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return js.jobRepo.GetJobGraph(ctx, owner, jobID)
+}
+
+func (js *jobService) ListJobs(ctx context.Context, listDTO domain.JobListRequestDTO) (*domain.JobListResponseDTO, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if listDTO.Sort == "" {
+		listDTO.Sort = defaultJobSort
+	}
+	filter, appErr := newJobFilter(listDTO)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// Fetch one job more than asked for to know whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	jobs, appErr := js.jobRepo.ListJobs(ctx, userID, *filter)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	response := domain.JobListResponseDTO{Jobs: jobs}
+	if len(jobs) > limit {
+		response.Jobs = jobs[:limit]
+		last := response.Jobs[limit-1]
+		cursor := domain.JobCursor{Sort: listDTO.Sort, Value: last.CreatedAt, ID: last.ID}
+		if filter.SortBy == "run_at" {
+			cursor.Value = last.RunAt
+		}
+		response.NextCursor = encodeJobCursor(cursor)
+	}
+
+	if listDTO.Count {
+		total, appErr := js.jobRepo.CountJobs(ctx, userID, *filter)
+		if appErr != nil {
+			return nil, appErr
+		}
+		response.Total = &total
+	}
+	return &response, nil
+}
+
+func (js *jobService) GetQueueStats(ctx context.Context) (*domain.QueueStatsResponseDTO, *common.AppError) {
+	counts, appErr := js.jobRepo.CountJobsByStatus(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	// Report every status, including those no job is in
+	stats := domain.QueueStatsResponseDTO{Statuses: map[string]int{}}
+	for _, status := range domain.JobStatuses {
+		stats.Statuses[status] = 0
+	}
+	for status, count := range counts {
+		stats.Statuses[status] = count
+		stats.Total += count
+	}
+	return &stats, nil
+}
+
+func (js *jobService) GetJobEvents(ctx context.Context, jobID uuid.UUID) ([]domain.JobEvent, *common.AppError) {
+	owner, appErr := jobOwner(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return js.jobRepo.GetJobEvents(ctx, owner, jobID)
+}
+
+func (js *jobService) CancelJob(ctx context.Context, jobID uuid.UUID) *common.AppError {
+	owner, appErr := jobOwner(ctx)
+	if appErr != nil {
+		return appErr
+	}
+
+	previousStatus, appErr := js.jobRepo.CancelJob(ctx, owner, jobID)
+	if appErr != nil {
+		return appErr
+	}
+
+	// Pending and retrying jobs only live in the scheduler heap, which drops them on the update notification
+	if previousStatus == "queued" || previousStatus == "processing" {
+		if err := js.dispatcher.CancelJob(ctx, jobID); err != nil {
+			return common.NewUnexpectedServerError("Failed to cancel queued task", err)
+		}
+	}
+	return nil
 }
 
 func (js *jobService) GetJobStatus(ctx context.Context, jobID uuid.UUID) (*domain.JobStatusResponseDTO, *common.AppError) {
+	owner, appErr := jobOwner(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
 	// Retrieve job status from the repository
-	job, appErr := js.jobRepo.GetJobStatus(ctx, jobID)
+	job, appErr := js.jobRepo.GetJobStatus(ctx, owner, jobID)
 	if appErr != nil {
 		return nil, appErr
 	}
 
-	if job == nil {
-		return nil, common.NewNotFoundError("Job not found")
+	// Only jobs waiting for the scheduler age
+	job.EffectivePriority = job.Priority
+	if job.Status == "pending" || job.Status == "retrying" {
+		job.EffectivePriority = priority.Effective(job.Priority, job.RunAt, time.Now(), js.priorityAging)
 	}
 
 	return job, nil
@@ -85,8 +346,59 @@ func (js *jobService) GetJobPayload(ctx context.Context, jobID uuid.UUID) (*task
 		return nil, appErr
 	}
 
+	// Workers load any job, users only their own
+	if _, ok := middleware.GetUserID(ctx); ok {
+		owner, appErr := jobOwner(ctx)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if owner != nil && payload.UserID != *owner {
+			return nil, common.NewNotFoundError("Job not found")
+		}
+	}
 	return payload, nil
 }
+
+func (js *jobService) GetRecoverableJobs(ctx context.Context) ([]task.JobPayload, *common.AppError) {
+	// Retrieve pending, retrying and queued jobs from the repository
+	jobs, appErr := js.jobRepo.GetRecoverableJobs(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return jobs, nil
+}
+
+func (js *jobService) ClaimJobs(ctx context.Context, workerID string, lease time.Duration, limit int) ([]task.JobPayload, *common.AppError) {
+	// Lease due jobs in the repository
+	jobs, appErr := js.jobRepo.ClaimJobs(ctx, workerID, lease, limit)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return jobs, nil
+}
+
+func (js *jobService) RenewJobLease(ctx context.Context, jobID uuid.UUID, workerID string, lease time.Duration) *common.AppError {
+	// Extend the lease in the repository
+	return js.jobRepo.RenewJobLease(ctx, jobID, workerID, lease)
+}
+
+func (js *jobService) FinishClaimedJob(ctx context.Context, jobID uuid.UUID, workerID string, status string, runAt time.Time, lastError string, result json.RawMessage) *common.AppError {
+	// Release the lease in the repository
+	return js.jobRepo.FinishClaimedJob(ctx, jobID, workerID, status, runAt, lastError, result)
+}
+
+func (js *jobService) SaveJobResult(ctx context.Context, jobID uuid.UUID, result json.RawMessage) *common.AppError {
+	// Store the result in the repository
+	return js.jobRepo.SaveJobResult(ctx, jobID, result)
+}
+
+func (js *jobService) SaveJobProgress(ctx context.Context, jobID uuid.UUID, percent int, message string) *common.AppError {
+	// Store the progress in the repository
+	return js.jobRepo.SaveJobProgress(ctx, jobID, percent, message)
+}
+
 func (js *jobService) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, attempts int) (*domain.Job, *common.AppError) {
 	// Update job status in the repository
 	job, appErr := js.jobRepo.UpdateJobStatus(ctx, jobID, status, attempts)
@@ -97,8 +409,156 @@ func (js *jobService) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, stat
 	return job, nil
 }
 
-func NewJobService(jobRepo repository.JobRepository) *jobService {
+func (js *jobService) QueueJob(ctx context.Context, jobID uuid.UUID, attempts int) (json.RawMessage, *common.AppError) {
+	// Queue the job in the repository
+	return js.jobRepo.QueueJob(ctx, jobID, attempts)
+}
+
+func (js *jobService) RetryJob(ctx context.Context, jobID uuid.UUID, attempts int, runAt time.Time, lastError string) *common.AppError {
+	// Schedule the next attempt in the repository
+	return js.jobRepo.RetryJob(ctx, jobID, attempts, runAt, lastError)
+}
+
+func (js *jobService) FailJob(ctx context.Context, jobID uuid.UUID, attempts int, lastError string) *common.AppError {
+	// Mark the job as failed and dead-letter it in the repository
+	return js.jobRepo.FailJob(ctx, jobID, attempts, lastError)
+}
+
+// maxJobKeyLength bounds idempotency and unique keys.
+const maxJobKeyLength = 255
+
+const (
+	// defaultJobSort lists the newest jobs first
+	defaultJobSort      = "-created_at"
+	defaultJobListLimit = 50
+	maxJobListLimit     = 500
+)
+
+// normalizeDependencies validates dependency edges, defaulting their failure
+// policy and dropping repeated parents.
+func normalizeDependencies(dependencies []domain.JobDependency) ([]domain.JobDependency, *common.AppError) {
+	seen := make(map[uuid.UUID]bool, len(dependencies))
+	normalized := make([]domain.JobDependency, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		if dependency.JobID == uuid.Nil {
+			return nil, common.NewBadRequestError("Invalid dependency job ID")
+		}
+		switch dependency.OnFailure {
+		case "":
+			dependency.OnFailure = domain.DependencyOnFailureFail
+		case domain.DependencyOnFailureFail, domain.DependencyOnFailureSkip:
+		default:
+			return nil, common.NewBadRequestError("Invalid dependency policy, expected fail or skip")
+		}
+		if seen[dependency.JobID] {
+			continue
+		}
+		seen[dependency.JobID] = true
+		normalized = append(normalized, dependency)
+	}
+	return normalized, nil
+}
+
+func NewJobService(jobRepo repository.JobRepository, dispatcher enqueue.Dispatcher, types *jobtype.Registry, priorityAging time.Duration) *jobService {
 	return &jobService{
-		jobRepo: jobRepo,
+		jobRepo:       jobRepo,
+		dispatcher:    dispatcher,
+		types:         types,
+		priorityAging: priorityAging,
+	}
+}
+
+// newJobFilter checks the parameters of a job listing.
+func newJobFilter(listDTO domain.JobListRequestDTO) (*domain.JobFilter, *common.AppError) {
+	filter := domain.JobFilter{
+		Statuses:   listDTO.Statuses,
+		Types:      listDTO.Types,
+		Priorities: listDTO.Priorities,
+		Limit:      listDTO.Limit,
+	}
+
+	for _, status := range filter.Statuses {
+		if !slices.Contains(domain.JobStatuses, status) {
+			return nil, common.NewBadRequestError("Invalid status: " + status)
+		}
+	}
+	for _, name := range filter.Priorities {
+		if !priority.Valid(name) {
+			return nil, common.NewBadRequestError("Invalid priority: " + name)
+		}
+	}
+
+	bounds := []struct {
+		param string
+		value string
+		bound **time.Time
+	}{
+		{"run_at_from", listDTO.RunAtFrom, &filter.RunAtFrom},
+		{"run_at_to", listDTO.RunAtTo, &filter.RunAtTo},
+		{"created_at_from", listDTO.CreatedFrom, &filter.CreatedFrom},
+		{"created_at_to", listDTO.CreatedTo, &filter.CreatedTo},
+	}
+	for _, b := range bounds {
+		if b.value == "" {
+			continue
+		}
+		t, err := parseTimeBound(b.value)
+		if err != nil {
+			return nil, common.NewBadRequestError("Invalid " + b.param + " format, expected RFC 3339")
+		}
+		*b.bound = &t
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(listDTO.Payload)) {
+		segments := strings.Split(path, ".")
+		if slices.Contains(segments, "") {
+			return nil, common.NewBadRequestError("Invalid payload filter: " + path)
+		}
+		filter.Payload = append(filter.Payload, domain.PayloadMatch{Path: segments, Value: listDTO.Payload[path]})
+	}
+
+	filter.SortBy, filter.Descending = strings.TrimPrefix(listDTO.Sort, "-"), strings.HasPrefix(listDTO.Sort, "-")
+	if filter.SortBy != "created_at" && filter.SortBy != "run_at" {
+		return nil, common.NewBadRequestError("Invalid sort, expected created_at or run_at with an optional - prefix")
+	}
+
+	if listDTO.Cursor != "" {
+		cursor, err := decodeJobCursor(listDTO.Cursor)
+		if err != nil || cursor.Sort != listDTO.Sort {
+			return nil, common.NewBadRequestError("Invalid cursor")
+		}
+		filter.After = cursor
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultJobListLimit
+	}
+	filter.Limit = min(filter.Limit, maxJobListLimit)
+	return &filter, nil
+}
+
+// parseTimeBound accepts RFC 3339 times as well as the local times jobs are created with.
+func parseTimeBound(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", value, common.DhakaTZ)
+}
+
+// encodeJobCursor turns a cursor into the opaque token clients send back.
+func encodeJobCursor(cursor domain.JobCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJobCursor(token string) (*domain.JobCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var cursor domain.JobCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
 	}
+	return &cursor, nil
 }
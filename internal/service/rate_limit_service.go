@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/repository"
+	"github.com/Nezent/go-queue/internal/worker/ratelimit"
+)
+
+type RateLimitService interface {
+	// GetRateLimits retrieves the dispatch token buckets of the leader as of now.
+	GetRateLimits(context.Context) (*ratelimit.State, *common.AppError)
+	// SaveRateLimits records the dispatch token buckets of the leader, taken at takenAt.
+	SaveRateLimits(context.Context, ratelimit.State, time.Time) *common.AppError
+}
+
+type rateLimitService struct {
+	repo repository.RateLimitRepository
+}
+
+func NewRateLimitService(repo repository.RateLimitRepository) RateLimitService {
+	return &rateLimitService{repo: repo}
+}
+
+func (rs *rateLimitService) GetRateLimits(ctx context.Context) (*ratelimit.State, *common.AppError) {
+	state, takenAt, appErr := rs.repo.GetRateLimitState(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+	// No scheduler ever led, so no bucket is in use
+	if state == nil {
+		return &ratelimit.State{Types: []ratelimit.Bucket{}, Users: []ratelimit.Bucket{}}, nil
+	}
+
+	// The buckets kept refilling since they were saved
+	advanced := state.Advance(time.Since(takenAt))
+	return &advanced, nil
+}
+
+func (rs *rateLimitService) SaveRateLimits(ctx context.Context, state ratelimit.State, takenAt time.Time) *common.AppError {
+	return rs.repo.SaveRateLimitState(ctx, state, takenAt)
+}
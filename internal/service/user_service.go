@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/Nezent/go-queue/common"
 	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
 	"github.com/Nezent/go-queue/internal/repository"
 	"github.com/Nezent/go-queue/internal/worker/enqueue"
 	"github.com/Nezent/go-queue/internal/worker/task"
@@ -13,15 +15,43 @@ import (
 
 type UserService interface {
 	RegisterUser(context.Context, domain.UserRegisterDTO) (*domain.UserResponseDTO, *common.AppError)
-	LoginUser(context.Context, domain.UserLoginRequestDTO) (*uuid.UUID, *common.AppError)
+	// LoginUser checks a user's credentials and returns their ID and role.
+	LoginUser(context.Context, domain.UserLoginRequestDTO) (*domain.User, *common.AppError)
 	VerifyUser(context.Context, string) *common.AppError
+	// GetWebhookSecret retrieves the current user's webhook secret.
+	GetWebhookSecret(context.Context) (*domain.WebhookSecretResponseDTO, *common.AppError)
+	// RotateWebhookSecret replaces the current user's webhook secret.
+	RotateWebhookSecret(context.Context) (*domain.WebhookSecretResponseDTO, *common.AppError)
+	// LookupWebhookSecret retrieves the webhook secret of any user, for workers signing requests.
+	LookupWebhookSecret(context.Context, uuid.UUID) (string, *common.AppError)
+	// ListUsers retrieves the users of the queue, for admins.
+	ListUsers(context.Context, int) ([]domain.UserSummaryDTO, *common.AppError)
+	// UpdateUserRole changes the role of another user.
+	UpdateUserRole(context.Context, uuid.UUID, domain.UserRoleUpdateRequestDTO) (*domain.UserSummaryDTO, *common.AppError)
+	// CreateAdmin creates the first admin, or promotes an existing user.
+	CreateAdmin(context.Context, domain.UserRegisterDTO) (*domain.UserSummaryDTO, *common.AppError)
+	// IssueRefreshToken starts a session for a user who just logged in.
+	IssueRefreshToken(context.Context, uuid.UUID) (string, *common.AppError)
+	// RefreshSession rotates a refresh token and returns the user it belongs to with its successor.
+	RefreshSession(context.Context, string) (*domain.User, string, *common.AppError)
+	// Logout revokes the session a refresh token belongs to.
+	Logout(context.Context, string) *common.AppError
 }
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for the next one.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+const (
+	defaultUserLimit = 50
+	maxUserLimit     = 500
+)
+
 type userService struct {
 	repo       repository.UserRepository
-	dispatcher *enqueue.TaskDispatcher
+	dispatcher enqueue.Dispatcher
 }
 
-func NewUserService(repo repository.UserRepository, dispatcher *enqueue.TaskDispatcher) UserService {
+func NewUserService(repo repository.UserRepository, dispatcher enqueue.Dispatcher) UserService {
 	return &userService{repo: repo, dispatcher: dispatcher}
 }
 func (us *userService) RegisterUser(context context.Context, user domain.UserRegisterDTO) (*domain.UserResponseDTO, *common.AppError) {
@@ -60,7 +90,7 @@ func (us *userService) RegisterUser(context context.Context, user domain.UserReg
 	return responseDTO, nil
 }
 
-func (us *userService) LoginUser(ctx context.Context, user domain.UserLoginRequestDTO) (*uuid.UUID, *common.AppError) {
+func (us *userService) LoginUser(ctx context.Context, user domain.UserLoginRequestDTO) (*domain.User, *common.AppError) {
 	// Validate user data
 	if user.Email == "" || user.Password == "" {
 		return nil, common.NewBadRequestError("Email and password are required")
@@ -72,11 +102,11 @@ func (us *userService) LoginUser(ctx context.Context, user domain.UserLoginReque
 		return nil, common.NewBadRequestError("Password must be at least 6 characters long")
 	}
 
-	userID, err := us.repo.LoginUser(ctx, user.Email, user.Password)
+	loggedIn, err := us.repo.LoginUser(ctx, user.Email, user.Password)
 	if err != nil {
 		return nil, err
 	}
-	return userID, nil
+	return loggedIn, nil
 }
 
 func (us *userService) VerifyUser(ctx context.Context, token string) *common.AppError {
@@ -93,7 +123,135 @@ func (us *userService) VerifyUser(ctx context.Context, token string) *common.App
 	return nil
 }
 
-func sendVerification(context context.Context, email string, token string, dispatcher *enqueue.TaskDispatcher) {
+func (us *userService) GetWebhookSecret(ctx context.Context) (*domain.WebhookSecretResponseDTO, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	secret, appErr := us.repo.GetWebhookSecret(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &domain.WebhookSecretResponseDTO{WebhookSecret: secret}, nil
+}
+
+func (us *userService) RotateWebhookSecret(ctx context.Context) (*domain.WebhookSecretResponseDTO, *common.AppError) {
+	userID, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	secret, appErr := us.repo.RotateWebhookSecret(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &domain.WebhookSecretResponseDTO{WebhookSecret: secret}, nil
+}
+
+func (us *userService) LookupWebhookSecret(ctx context.Context, userID uuid.UUID) (string, *common.AppError) {
+	// Retrieve the secret from the repository
+	return us.repo.GetWebhookSecret(ctx, userID)
+}
+
+func (us *userService) ListUsers(ctx context.Context, limit int) ([]domain.UserSummaryDTO, *common.AppError) {
+	if limit <= 0 {
+		limit = defaultUserLimit
+	}
+	limit = min(limit, maxUserLimit)
+
+	return us.repo.ListUsers(ctx, limit)
+}
+
+func (us *userService) UpdateUserRole(ctx context.Context, userID uuid.UUID, roleDTO domain.UserRoleUpdateRequestDTO) (*domain.UserSummaryDTO, *common.AppError) {
+	currentUser, appErr := currentUserID(ctx)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !middleware.ValidRole(roleDTO.Role) {
+		return nil, common.NewBadRequestError("Invalid role")
+	}
+	// Keeps the last admin from locking everyone out of user management
+	if userID == currentUser {
+		return nil, common.NewBadRequestError("You can't change your own role")
+	}
+
+	return us.repo.UpdateUserRole(ctx, userID, roleDTO.Role)
+}
+
+func (us *userService) CreateAdmin(ctx context.Context, user domain.UserRegisterDTO) (*domain.UserSummaryDTO, *common.AppError) {
+	// Validate user data
+	if user.Name == "" || user.Email == "" || user.Password == "" {
+		return nil, common.NewBadRequestError("Name, email, and password are required")
+	}
+	if !common.ValidateEmailWithRegex(user.Email) {
+		return nil, common.NewBadRequestError("Invalid email format")
+	}
+	if len(user.Password) < 6 {
+		return nil, common.NewBadRequestError("Password must be at least 6 characters long")
+	}
+
+	return us.repo.CreateAdmin(ctx, domain.User{
+		Name:     user.Name,
+		Email:    user.Email,
+		Password: user.Password,
+	})
+}
+
+func (us *userService) IssueRefreshToken(ctx context.Context, userID uuid.UUID) (string, *common.AppError) {
+	token, err := common.GenerateRefreshToken()
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Failed to generate refresh token", err)
+	}
+	tokenHash, err := common.GenerateHash(token)
+	if err != nil {
+		return "", common.NewUnexpectedServerError("Failed to generate refresh token", err)
+	}
+
+	if appErr := us.repo.CreateRefreshToken(ctx, userID, *tokenHash, time.Now().Add(RefreshTokenTTL)); appErr != nil {
+		return "", appErr
+	}
+	return token, nil
+}
+
+func (us *userService) RefreshSession(ctx context.Context, token string) (*domain.User, string, *common.AppError) {
+	if token == "" {
+		return nil, "", common.NewUnauthorizedError("Refresh token is required")
+	}
+	tokenHash, err := common.GenerateHash(token)
+	if err != nil {
+		return nil, "", common.NewUnexpectedServerError("Failed to hash refresh token", err)
+	}
+
+	newToken, err := common.GenerateRefreshToken()
+	if err != nil {
+		return nil, "", common.NewUnexpectedServerError("Failed to generate refresh token", err)
+	}
+	newTokenHash, err := common.GenerateHash(newToken)
+	if err != nil {
+		return nil, "", common.NewUnexpectedServerError("Failed to generate refresh token", err)
+	}
+
+	user, appErr := us.repo.RotateRefreshToken(ctx, *tokenHash, *newTokenHash, time.Now().Add(RefreshTokenTTL))
+	if appErr != nil {
+		return nil, "", appErr
+	}
+	return user, newToken, nil
+}
+
+func (us *userService) Logout(ctx context.Context, token string) *common.AppError {
+	// Without a refresh token only the cookies can be cleared
+	if token == "" {
+		return nil
+	}
+	tokenHash, err := common.GenerateHash(token)
+	if err != nil {
+		return common.NewUnexpectedServerError("Failed to hash refresh token", err)
+	}
+	return us.repo.RevokeRefreshTokenFamily(ctx, *tokenHash)
+}
+
+func sendVerification(context context.Context, email string, token string, dispatcher enqueue.Dispatcher) {
 
 	_ = dispatcher.EnqueueSendVerificationEmail(context, task.SendVerificationEmailPayload{
 		Email: email,
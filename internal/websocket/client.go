@@ -3,6 +3,7 @@ package websocket
 import (
 	"sync"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -10,6 +11,10 @@ type Client struct {
 	Conn  *websocket.Conn
 	Send  chan []byte
 	Mutex sync.Mutex
+	// UserID is the user the connection was opened by
+	UserID uuid.UUID
+	// WatchesQueue is set for roles that may see the events of every user
+	WatchesQueue bool
 }
 
 func (c *Client) Write(msg []byte) {
@@ -17,3 +22,9 @@ func (c *Client) Write(msg []byte) {
 	defer c.Mutex.Unlock()
 	c.Conn.WriteMessage(websocket.TextMessage, msg)
 }
+
+// Receives reports whether the client may see events about what owner owns.
+// Events of system jobs, owned by uuid.Nil, only go to queue watchers.
+func (c *Client) Receives(owner uuid.UUID) bool {
+	return c.WatchesQueue || (owner != uuid.Nil && owner == c.UserID)
+}
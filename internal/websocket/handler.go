@@ -3,6 +3,9 @@ package websocket
 import (
 	"net/http"
 
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -10,14 +13,27 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
+// HandleWebSocket registers the connection for the events of the
+// authenticated user, or of every user for roles that may view the queue. It
+// must run after AuthMiddleware.
 func HandleWebSocket(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	rawUserID, _ := middleware.GetUserID(r.Context())
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		common.RespondJSON(w, http.StatusUnauthorized, common.ErrorResponse("Unauthorized - invalid user ID"))
+		return
+	}
+	role, _ := middleware.GetUserRole(r.Context())
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
 	client := &Client{
-		Conn: conn,
-		Send: make(chan []byte, 256),
+		Conn:         conn,
+		Send:         make(chan []byte, 256),
+		UserID:       userID,
+		WatchesQueue: middleware.HasPermission(role, middleware.PermissionViewQueue),
 	}
 
 	hub.Register <- client
@@ -1,10 +1,20 @@
 package websocket
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Message is an event about something owner owns, uuid.Nil for the system.
+type Message struct {
+	Owner uuid.UUID
+	Data  []byte
+}
 
 type Hub struct {
 	Clients    map[*Client]bool
-	Broadcast  chan []byte
+	Owned      chan Message
 	Register   chan *Client
 	Unregister chan *Client
 	Mutex      sync.Mutex
@@ -13,7 +23,7 @@ type Hub struct {
 func NewHub() *Hub {
 	return &Hub{
 		Clients:    make(map[*Client]bool),
-		Broadcast:  make(chan []byte),
+		Owned:      make(chan Message),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 	}
@@ -30,12 +40,20 @@ func (h *Hub) Run() {
 			h.Mutex.Lock()
 			delete(h.Clients, client)
 			h.Mutex.Unlock()
-		case message := <-h.Broadcast:
+		case message := <-h.Owned:
 			h.Mutex.Lock()
 			for c := range h.Clients {
-				go c.Write(message)
+				if c.Receives(message.Owner) {
+					go c.Write(message.Data)
+				}
 			}
 			h.Mutex.Unlock()
 		}
 	}
 }
+
+// PublishTo sends a message about something owner owns to the clients of
+// owner and to the clients watching the whole queue.
+func (h *Hub) PublishTo(owner uuid.UUID, message []byte) {
+	h.Owned <- Message{Owner: owner, Data: message}
+}
@@ -3,19 +3,37 @@ package enqueue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
 	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
+// defaultQueue is the asynq queue tasks are enqueued into.
+const defaultQueue = "default"
+
+// Dispatcher enqueues tasks for the background workers.
+type Dispatcher interface {
+	EnqueueSendVerificationEmail(context.Context, task.SendVerificationEmailPayload) error
+	// EnqueueJob enqueues the task running a job of the given type.
+	EnqueueJob(context.Context, uuid.UUID, jobtype.Type, json.RawMessage) error
+	// CancelJob withdraws a job that was already handed to the broker.
+	CancelJob(context.Context, uuid.UUID) error
+}
+
+// TaskDispatcher enqueues tasks into asynq/Redis.
 type TaskDispatcher struct {
-	Client *asynq.Client
+	Client    *asynq.Client
+	Inspector *asynq.Inspector
 }
 
 func NewTaskDispatcher(redisOpt asynq.RedisClientOpt) *TaskDispatcher {
 	return &TaskDispatcher{
-		Client: asynq.NewClient(redisOpt),
+		Client:    asynq.NewClient(redisOpt),
+		Inspector: asynq.NewInspector(redisOpt),
 	}
 }
 
@@ -31,14 +49,38 @@ func (d *TaskDispatcher) EnqueueSendVerificationEmail(ctx context.Context, paylo
 	return err
 }
 
-func (d *TaskDispatcher) EnqueueSendJobEmail(ctx context.Context, payload task.EmailPayload) error {
-	data, err := json.Marshal(payload)
+// EnqueueJob enqueues the task of a job. The task ID is the job ID so workers
+// can record the outcome, and asynq never retries it: failed attempts are
+// rescheduled through the scheduler so the job keeps a single attempts counter.
+func (d *TaskDispatcher) EnqueueJob(ctx context.Context, jobID uuid.UUID, jobType jobtype.Type, payload json.RawMessage) error {
+	task := asynq.NewTask(jobType.TaskName, payload)
+
+	_, err := d.Client.EnqueueContext(ctx, task, asynq.TaskID(jobID.String()), asynq.MaxRetry(0), asynq.Timeout(jobType.Timeout))
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		// The job is already queued, a second task would run it twice
+		return nil
+	}
+	return err
+}
+
+// CancelJob deletes the task of a job from its queue, or signals the worker
+// running it to stop. A job without a task has nothing to withdraw.
+func (d *TaskDispatcher) CancelJob(ctx context.Context, jobID uuid.UUID) error {
+	info, err := d.Inspector.GetTaskInfo(defaultQueue, jobID.String())
+	if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	task := asynq.NewTask(task.TaskSendJobEmail, data)
-
-	_, err = d.Client.EnqueueContext(ctx, task, asynq.MaxRetry(5), asynq.Timeout(30*time.Second))
+	if info.State == asynq.TaskStateActive {
+		return d.Inspector.CancelProcessing(info.ID)
+	}
+	err = d.Inspector.DeleteTask(info.Queue, info.ID)
+	if errors.Is(err, asynq.ErrTaskNotFound) {
+		// The task finished in the meantime
+		return nil
+	}
 	return err
 }
@@ -0,0 +1,47 @@
+package enqueue
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgTaskDispatcher enqueues tasks as rows of the jobs table, where workers
+// running the Postgres broker claim them. It needs no Redis.
+type PgTaskDispatcher struct {
+	db *pgxpool.Pool
+}
+
+func NewPgTaskDispatcher(db *pgxpool.Pool) *PgTaskDispatcher {
+	return &PgTaskDispatcher{db: db}
+}
+
+func (d *PgTaskDispatcher) EnqueueSendVerificationEmail(ctx context.Context, payload task.SendVerificationEmailPayload) error {
+	return d.insertJob(ctx, task.TaskSendVerificationEmail, payload, "high")
+}
+
+// EnqueueJob makes an existing job claimable right away; the row already
+// carries its type and payload.
+func (d *PgTaskDispatcher) EnqueueJob(ctx context.Context, jobID uuid.UUID, _ jobtype.Type, _ json.RawMessage) error {
+	_, err := d.db.Exec(ctx, `UPDATE jobs SET status = 'pending', run_at = now() WHERE id = $1`, jobID)
+	return err
+}
+
+// CancelJob has nothing to withdraw: workers only claim jobs by their status,
+// and cancelling a job drops the lease of any worker running it.
+func (d *PgTaskDispatcher) CancelJob(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (d *PgTaskDispatcher) insertJob(ctx context.Context, taskType string, payload any, priority string) error {
+	query := `
+		INSERT INTO jobs (type, payload, status, priority, attempts, run_at)
+		VALUES ($1, $2, 'pending', $3, 0, now())
+	`
+	_, err := d.db.Exec(ctx, query, taskType, payload, priority)
+	return err
+}
@@ -1,20 +1,13 @@
 package worker
 
 import (
-	"container/heap"
-	"context"
 	"encoding/json"
-	"log"
-	"sync"
 	"time"
 
-	"github.com/Nezent/go-queue/common"
-	"github.com/Nezent/go-queue/internal/bootstrap"
-	"github.com/Nezent/go-queue/internal/middleware"
-	"github.com/Nezent/go-queue/internal/worker/enqueue"
+	"github.com/Nezent/go-queue/internal/worker/priority"
+	"github.com/Nezent/go-queue/internal/worker/retry"
 	"github.com/Nezent/go-queue/internal/worker/task"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type JobItem struct {
@@ -22,10 +15,17 @@ type JobItem struct {
 	RunAt    time.Time
 	Priority int
 	Attempts int
-	Payload  task.EmailPayload
+	Payload  json.RawMessage
 	JobType  string
 	Status   string
-	index    int // required by heap.Interface
+	Retry    *retry.Override // per-job override of the job type's retry policy
+	UserID   uuid.UUID       // uuid.Nil for system jobs
+	// admitted is set once the rate limiter booked a dispatch slot for the job
+	admitted bool
+	ready    bool // due, waiting in the ready queue rather than the heap
+	// agedRunAt orders the job among due jobs, see readyHeap
+	agedRunAt time.Time
+	index     int // required by heap.Interface
 }
 
 type JobPriorityQueue []*JobItem
@@ -62,114 +62,17 @@ func (pq *JobPriorityQueue) Pop() any {
 	return item
 }
 
-var (
-	jobQueue     JobPriorityQueue
-	queueMutex   sync.Mutex
-	jobQueueCond = sync.NewCond(&queueMutex)
-)
-
-func InitJobQueue(ctx context.Context, dispatcher *enqueue.TaskDispatcher, c *bootstrap.Container, db *pgxpool.Pool) {
-	heap.Init(&jobQueue)
-	go processJobs(ctx, &jobQueue, dispatcher, c, db)
-}
-
-func processJobs(ctx context.Context, jobQueue *JobPriorityQueue, dispatcher *enqueue.TaskDispatcher, c *bootstrap.Container, db *pgxpool.Pool) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			queueMutex.Lock()
-
-			if len(*jobQueue) == 0 {
-				log.Println("Job queue is empty, waiting for jobs...")
-				jobQueueCond.Wait()
-
-				if ctx.Err() != nil {
-					queueMutex.Unlock()
-					return
-				}
-				queueMutex.Unlock()
-				continue
-			}
-
-			now := time.Now().In(common.DhakaTZ).Truncate(time.Second)
-			nextJob := (*jobQueue)[0]
-
-			if nextJob.RunAt.After(now) {
-				queueMutex.Unlock()
-				continue
-			}
-			// Remove the job from the queue
-			heap.Pop(jobQueue)
-			queueMutex.Unlock()
-			// Process the job
-			log.Printf("[PROCESS] Processing job ID %s...\n", nextJob.ID)
-
-			nextJob.Attempts++
-			if nextJob.Attempts > 3 {
-				log.Printf("[PROCESS] Job ID %s failed after 3 attempts, marking as failed.\n", nextJob.ID)
-				nextJob.Status = "failed"
-				err := updateJobStatus(ctx, nextJob.ID, nextJob.Status, nextJob.Attempts, c, db)
-				if err != nil {
-					log.Printf("[ERROR] Failed to update job status: %v\n", err)
-				}
-				continue
-			}
-
-			err := dispatcher.EnqueueSendJobEmail(ctx, nextJob.Payload)
-			if err != nil {
-				nextJob.Attempts++
-				log.Printf("[PROCESS] Job ID %s failed, retrying... (Attempt %d)\n", nextJob.ID, nextJob.Attempts)
-				nextJob.Status = "processing"
-				nextJob.RunAt = time.Now().Add(time.Duration(nextJob.Attempts) * time.Minute)
-				nextJob.Priority = 1
-				err = updateJobStatus(ctx, nextJob.ID, nextJob.Status, nextJob.Attempts, c, db)
-				if err != nil {
-					log.Printf("[ERROR] Failed to update job status: %v\n", err)
-				}
-				queueMutex.Lock()
-				heap.Push(jobQueue, nextJob)
-				jobQueueCond.Signal()
-				queueMutex.Unlock()
-			} else {
-				jsonMsg := task.WebSocketPayload{
-					JobID:   nextJob.ID.String(),
-					JobType: nextJob.JobType,
-					Status:  "completed",
-				}
-				nextJob.Status = "completed"
-				err = updateJobStatus(ctx, nextJob.ID, nextJob.Status, nextJob.Attempts, c, db)
-				if err != nil {
-					log.Printf("[ERROR] Failed to update job status: %v\n", err)
-				}
-				jsonMsgBytes, err := json.Marshal(jsonMsg)
-				if err != nil {
-					log.Printf("[LISTENER] Failed to marshal JSON for job ID %s: %v\n", nextJob.ID, err)
-					continue
-				}
-				c.WebSocketHub.Broadcast <- jsonMsgBytes
-				log.Printf("[PROCESS] Job ID %s executed successfully.\n", nextJob.ID)
-			}
-		}
-	}
-}
-
-func updateJobStatus(ctx context.Context, jobID uuid.UUID, status string, attempts int, c *bootstrap.Container, db *pgxpool.Pool) error {
-	tx, err := db.Begin(ctx)
-	if err != nil {
-		log.Println("failed to start transaction:", err)
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	ctx = context.WithValue(ctx, middleware.TxKey, tx)
-	appErr := c.JobHandler.UpdateJobStatus(ctx, jobID, status, attempts)
-	if appErr != nil {
-		return appErr
+// newJobItem builds a heap entry from a job loaded from the database.
+func newJobItem(jobPayload task.JobPayload) *JobItem {
+	return &JobItem{
+		ID:       jobPayload.ID,
+		RunAt:    jobPayload.RunAt,
+		Priority: priority.Values[jobPayload.Priority],
+		Attempts: jobPayload.Attempts,
+		Payload:  jobPayload.Payload,
+		JobType:  jobPayload.JobType,
+		Status:   jobPayload.Status,
+		Retry:    jobPayload.Retry,
+		UserID:   jobPayload.UserID,
 	}
-	return tx.Commit(ctx)
 }
@@ -0,0 +1,143 @@
+package jobtype
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/hibiken/asynq"
+)
+
+// DefaultPriority and DefaultTimeout apply to types that don't set their own.
+const (
+	DefaultPriority = "medium"
+	DefaultTimeout  = 30 * time.Second
+)
+
+// Decoder parses the payload of a job and reports why it is invalid.
+type Decoder func(json.RawMessage) error
+
+// Validator is implemented by payloads that check their own fields.
+type Validator interface {
+	Validate() error
+}
+
+// JSON returns a Decoder unmarshalling payloads into T. Unknown fields are
+// rejected, and T's Validate method runs if it has one.
+func JSON[T any]() Decoder {
+	return func(data json.RawMessage) error {
+		var payload T
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&payload); err != nil {
+			return err
+		}
+		if validator, ok := any(&payload).(Validator); ok {
+			return validator.Validate()
+		}
+		return nil
+	}
+}
+
+// Type describes a kind of job: how its payload is checked, which asynq task
+// runs it and the defaults of its jobs.
+type Type struct {
+	// Name is the type jobs are created with, stored in jobs.type
+	Name string
+	// TaskName is the asynq task type the job runs as, Name if empty
+	TaskName string
+	Decode   Decoder
+	Handler  asynq.HandlerFunc
+
+	// Priority is used when a job doesn't set one
+	Priority string
+	// Retry is the type's retry policy unless RETRY_POLICIES configures one
+	Retry *retry.Override
+	// Timeout bounds a single attempt
+	Timeout time.Duration
+	// Internal types are only enqueued by the system, never through the API
+	Internal bool
+}
+
+// Registry holds every job type the queue knows how to run.
+type Registry struct {
+	types map[string]Type
+	tasks map[string]Type
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		types: make(map[string]Type),
+		tasks: make(map[string]Type),
+	}
+}
+
+// Register adds a job type, filling in the defaults of unset fields. Like
+// http.ServeMux it panics on incomplete or conflicting registrations, which
+// are programming errors.
+func (r *Registry) Register(t Type) {
+	if t.Name == "" || t.Decode == nil || t.Handler == nil {
+		panic("jobtype: a job type needs a name, a decoder and a handler")
+	}
+	if t.TaskName == "" {
+		t.TaskName = t.Name
+	}
+	if t.Priority == "" {
+		t.Priority = DefaultPriority
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = DefaultTimeout
+	}
+
+	if _, ok := r.types[t.Name]; ok {
+		panic(fmt.Sprintf("jobtype: job type %q registered twice", t.Name))
+	}
+	if _, ok := r.tasks[t.TaskName]; ok {
+		panic(fmt.Sprintf("jobtype: task %q registered twice", t.TaskName))
+	}
+	r.types[t.Name] = t
+	r.tasks[t.TaskName] = t
+}
+
+// Lookup returns the job type called name.
+func (r *Registry) Lookup(name string) (Type, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// LookupTask returns the job type running as the asynq task taskName.
+func (r *Registry) LookupTask(taskName string) (Type, bool) {
+	t, ok := r.tasks[taskName]
+	return t, ok
+}
+
+// Types returns every registered job type ordered by name.
+func (r *Registry) Types() []Type {
+	types := make([]Type, 0, len(r.types))
+	for _, t := range r.types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// Policies adds the retry policies of the registered types to configured.
+// The fields configured for a type take precedence over its registered policy.
+func (r *Registry) Policies(configured retry.Policies) retry.Policies {
+	policies := retry.Policies{
+		Default: configured.Default,
+		Types:   make(map[string]retry.Override, len(r.types)),
+	}
+	for name, t := range r.types {
+		if t.Retry != nil {
+			policies.Types[name] = *t.Retry
+		}
+	}
+	for name, policy := range configured.Types {
+		policies.Types[name] = policies.Types[name].Merge(&policy)
+	}
+	return policies
+}
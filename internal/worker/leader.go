@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LeaderElector makes sure only one replica runs the scheduler at a time. The
+// leader holds a session-level Postgres advisory lock on a dedicated
+// connection; Postgres releases it as soon as that session dies, and a
+// follower polling every interval takes over.
+type LeaderElector struct {
+	db       *pgxpool.Pool
+	lockID   int64
+	interval time.Duration
+}
+
+func NewLeaderElector(db *pgxpool.Pool, lockID int64, interval time.Duration) *LeaderElector {
+	return &LeaderElector{db: db, lockID: lockID, interval: interval}
+}
+
+// Run campaigns for leadership until ctx is done. Each time this replica
+// becomes the leader, lead is called with a context that is cancelled once
+// leadership is lost.
+func (e *LeaderElector) Run(ctx context.Context, lead func(context.Context)) {
+	for {
+		e.campaign(ctx, lead)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// campaign tries to take the lock once and, if it succeeds, leads until the
+// session is lost, lead returns or ctx is done.
+func (e *LeaderElector) campaign(ctx context.Context, lead func(context.Context)) {
+	conn, err := e.db.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[LEADER] Failed to acquire connection: %v\n", err)
+		}
+		return
+	}
+	defer conn.Release()
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, e.lockID).Scan(&acquired); err != nil {
+		log.Printf("[LEADER] Failed to try advisory lock: %v\n", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	log.Println("[LEADER] Acquired scheduler lock, this replica is the leader")
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leaderCtx)
+	}()
+
+	// Check the session more often than followers poll, so a leader that lost
+	// its lock stops before another replica could have taken over
+	ticker := time.NewTicker(e.interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			e.unlock(context.WithoutCancel(ctx), conn)
+			return
+		case <-done:
+			// lead gave up on its own, hand the lock to whoever campaigns next
+			cancel()
+			e.unlock(ctx, conn)
+			return
+		case <-ticker.C:
+			if err := conn.Ping(ctx); err != nil {
+				log.Printf("[LEADER] Lost the leader session: %v\n", err)
+				cancel()
+				<-done
+				// The lock died with the session; make sure the pool drops the connection
+				conn.Conn().Close(context.WithoutCancel(ctx))
+				return
+			}
+		}
+	}
+}
+
+// unlock releases the advisory lock unless the session is already gone.
+func (e *LeaderElector) unlock(ctx context.Context, conn *pgxpool.Conn) {
+	if conn.Conn().IsClosed() {
+		return
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, e.lockID); err != nil {
+		log.Printf("[LEADER] Failed to release scheduler lock: %v\n", err)
+		return
+	}
+	log.Println("[LEADER] Released scheduler lock")
+}
@@ -1,68 +1,205 @@
 package worker
 
 import (
-	"container/heap"
 	"context"
+	"encoding/json"
 	"log"
+	"sync"
 
 	"github.com/Nezent/go-queue/internal/bootstrap"
+	"github.com/Nezent/go-queue/internal/worker/task"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func StartPgListener(ctx context.Context, channel string, pool *pgxpool.Pool, c *bootstrap.Container) {
-	conn, _ := pool.Acquire(ctx)
-	defer conn.Release()
-	_, err := conn.Exec(ctx, `LISTEN `+channel)
+// Channels the notify_job_update trigger sends on.
+const (
+	jobUpdatesChannel  = "job_updates"
+	jobProgressChannel = "job_progress"
+)
+
+// EventPublisher sends job events to the subscribed clients allowed to see
+// what owner owns.
+type EventPublisher interface {
+	PublishTo(owner uuid.UUID, message []byte)
+}
+
+// JobNotifications fans the job notifications of Postgres out. It runs on
+// every replica with either broker: the WebSocket clients of the replica get
+// status, batch and progress events, and while the replica leads, its
+// scheduler is synced with the job updates.
+type JobNotifications struct {
+	pool      *pgxpool.Pool
+	c         *bootstrap.Container
+	publisher EventPublisher
+	// listening is closed once LISTEN was issued
+	listening chan struct{}
+
+	mu        sync.Mutex
+	scheduler *Scheduler
+}
+
+func NewJobNotifications(pool *pgxpool.Pool, c *bootstrap.Container) *JobNotifications {
+	return &JobNotifications{
+		pool:      pool,
+		c:         c,
+		publisher: c.WebSocketHub,
+		listening: make(chan struct{}),
+	}
+}
+
+// Run listens for job notifications until ctx is done.
+func (n *JobNotifications) Run(ctx context.Context) {
+	conn, err := n.pool.Acquire(ctx)
 	if err != nil {
-		log.Fatal("[LISTENER] LISTEN failed:", err)
+		log.Fatal("[LISTENER] Acquire failed:", err)
+	}
+	defer conn.Release()
+	for _, channel := range []string{jobUpdatesChannel, jobProgressChannel} {
+		if _, err := conn.Exec(ctx, `LISTEN `+channel); err != nil {
+			log.Fatal("[LISTENER] LISTEN failed:", err)
+		}
 	}
+	// The connection goes back to the pool, so stop listening once ctx is done
+	defer conn.Exec(context.WithoutCancel(ctx), `UNLISTEN *`)
 
-	log.Println("[LISTENER] Listening on channel:", channel)
+	log.Println("[LISTENER] Listening on channels:", jobUpdatesChannel, jobProgressChannel)
+	close(n.listening)
 	for {
 		notification, err := conn.Conn().WaitForNotification(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				log.Println("[LISTENER] Stopped listening")
+				return
+			}
 			log.Println("[LISTENER] Error while waiting:", err)
 			continue
 		}
 
-		jID := notification.Payload
-		log.Println("[LISTENER] Received notification for job ID:", jID)
-		jobID, err := uuid.Parse(jID)
-		if err != nil {
-			log.Printf("[LISTENER] Invalid job ID format: %s\n", jobID)
-			continue
+		switch notification.Channel {
+		case jobUpdatesChannel:
+			n.handleJobUpdate(ctx, notification.Payload)
+		case jobProgressChannel:
+			n.handleProgress(notification.Payload)
 		}
+	}
+}
 
-		jobPayload, err := c.JobHandler.GetJobPayload(ctx, jobID)
-		if err != nil {
-			log.Printf("[LISTENER] Failed to fetch job payload for ID %s: %v\n", jobID, err)
-			continue
-		}
+// Lead syncs scheduler with the job updates until ctx is done. It returns
+// once notifications are received, false if ctx was done first.
+func (n *JobNotifications) Lead(ctx context.Context, scheduler *Scheduler) bool {
+	select {
+	case <-n.listening:
+	case <-ctx.Done():
+		return false
+	}
 
-		priorityValue := map[string]int{
-			"high":   1,
-			"medium": 2,
-			"low":    3,
-		}[jobPayload.Priority]
-
-		job := &JobItem{
-			ID:       jobID,
-			RunAt:    jobPayload.RunAt,
-			Priority: priorityValue,
-			Attempts: jobPayload.Attempts,
-			Payload:  jobPayload.Payload,
-			JobType:  jobPayload.JobType,
-			Status:   jobPayload.Status,
+	n.mu.Lock()
+	n.scheduler = scheduler
+	n.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		if n.scheduler == scheduler {
+			n.scheduler = nil
 		}
+		n.mu.Unlock()
+	}()
+	return true
+}
 
-		if jobPayload.Status == "pending" {
-			log.Printf("[LISTENER] Enqueuing job ID %s with priority %d and run_at %s\n", jobID, priorityValue, jobPayload.RunAt)
+func (n *JobNotifications) handleJobUpdate(ctx context.Context, jID string) {
+	log.Println("[LISTENER] Received notification for job ID:", jID)
+	jobID, err := uuid.Parse(jID)
+	if err != nil {
+		log.Printf("[LISTENER] Invalid job ID format: %s\n", jID)
+		return
+	}
 
-			queueMutex.Lock()
-			heap.Push(&jobQueue, job)
-			jobQueueCond.Broadcast()
-			queueMutex.Unlock()
-		}
+	jobPayload, err := n.c.JobHandler.GetJobPayload(ctx, jobID)
+	if err != nil {
+		log.Printf("[LISTENER] Failed to fetch job payload for ID %s: %v\n", jobID, err)
+		return
+	}
+
+	n.publishStatus(*jobPayload)
+	n.mu.Lock()
+	scheduler := n.scheduler
+	n.mu.Unlock()
+	if scheduler != nil {
+		scheduler.Sync(*jobPayload)
+	}
+	if jobPayload.BatchID != nil {
+		n.publishBatchProgress(ctx, *jobPayload.BatchID)
+	}
+}
+
+// progressNotification is a WebSocket payload the database sends along with
+// the owner of its job, NULL for system jobs.
+type progressNotification struct {
+	task.WebSocketPayload
+	UserID *uuid.UUID `json:"user_id"`
+}
+
+// handleProgress forwards the progress reports of running jobs, which the
+// database sends as ready-made WebSocket payloads, to the WebSocket clients
+// of their owner.
+func (n *JobNotifications) handleProgress(payload string) {
+	var notification progressNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		log.Printf("[LISTENER] Invalid progress notification: %v\n", err)
+		return
+	}
+	jsonMsgBytes, err := json.Marshal(notification.WebSocketPayload)
+	if err != nil {
+		log.Printf("[LISTENER] Failed to marshal progress of job %s: %v\n", notification.JobID, err)
+		return
+	}
+
+	owner := uuid.Nil
+	if notification.UserID != nil {
+		owner = *notification.UserID
+	}
+	n.publisher.PublishTo(owner, jsonMsgBytes)
+}
+
+// publishStatus sends a job status change to the WebSocket clients of its owner.
+func (n *JobNotifications) publishStatus(jobPayload task.JobPayload) {
+	jsonMsg := task.WebSocketPayload{
+		Event:   "status",
+		JobID:   jobPayload.ID.String(),
+		JobType: jobPayload.JobType,
+		Status:  jobPayload.Status,
+	}
+	jsonMsgBytes, err := json.Marshal(jsonMsg)
+	if err != nil {
+		log.Printf("[LISTENER] Failed to marshal JSON for job ID %s: %v\n", jobPayload.ID, err)
+		return
+	}
+	n.publisher.PublishTo(jobPayload.UserID, jsonMsgBytes)
+}
+
+// publishBatchProgress sends the counts of a batch to the WebSocket clients of its owner.
+func (n *JobNotifications) publishBatchProgress(ctx context.Context, batchID uuid.UUID) {
+	batch, err := n.c.BatchHandler.GetBatchProgress(ctx, batchID)
+	if err != nil {
+		log.Printf("[LISTENER] Failed to fetch progress of batch %s: %v\n", batchID, err)
+		return
+	}
+
+	jsonMsg := task.BatchProgressPayload{
+		Event:     "batch_progress",
+		BatchID:   batch.ID.String(),
+		Total:     batch.Total,
+		Pending:   batch.Pending,
+		Completed: batch.Completed,
+		Failed:    batch.Failed,
+		Done:      batch.CompletedAt != nil,
+	}
+	jsonMsgBytes, err := json.Marshal(jsonMsg)
+	if err != nil {
+		log.Printf("[LISTENER] Failed to marshal progress of batch %s: %v\n", batchID, err)
+		return
 	}
+	n.publisher.PublishTo(batch.UserID, jsonMsgBytes)
 }
@@ -0,0 +1,22 @@
+package worker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/hibiken/asynq"
+)
+
+// nextState decides what happens to a job after an attempt ended with err:
+// it either completed, failed for good, or is retried at the returned time.
+func nextState(policy retry.Policy, attempts int, err error, now time.Time) (string, time.Time) {
+	switch {
+	case err == nil:
+		return "completed", now
+	case errors.Is(err, asynq.SkipRetry), policy.Exhausted(attempts):
+		return "failed", now
+	default:
+		return "retrying", now.Add(policy.Backoff(attempts))
+	}
+}
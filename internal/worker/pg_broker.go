@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/config"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// JobClaimer leases due jobs from the jobs table and releases them again,
+// recording the progress they report and renewing the lease in between.
+type JobClaimer interface {
+	JobProgressStore
+	ClaimJobs(context.Context, string, time.Duration, int) ([]task.JobPayload, error)
+	RenewJobLease(context.Context, uuid.UUID, string, time.Duration) error
+	FinishClaimedJob(context.Context, uuid.UUID, string, string, time.Time, string, json.RawMessage) error
+}
+
+// PgBroker runs jobs without Redis: each worker claims due rows from the jobs
+// table with SELECT ... FOR UPDATE SKIP LOCKED, holds them under a lease and
+// runs them through the same asynq handlers the Redis workers use.
+type PgBroker struct {
+	claimer  JobClaimer
+	types    *jobtype.Registry
+	handler  asynq.Handler
+	config   config.QueueConfig
+	policies retry.Policies
+	workerID string
+}
+
+func NewPgBroker(claimer JobClaimer, types *jobtype.Registry, handler asynq.Handler, cfg config.QueueConfig, policies retry.Policies) *PgBroker {
+	hostname, _ := os.Hostname()
+	return &PgBroker{
+		claimer:  claimer,
+		types:    types,
+		handler:  handler,
+		config:   cfg,
+		policies: policies,
+		workerID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Run starts the configured number of workers and blocks until ctx is done.
+func (b *PgBroker) Run(ctx context.Context) {
+	ctx = middleware.WithActor(ctx, "worker:"+b.workerID)
+	log.Printf("[BROKER] Worker %s claiming jobs from Postgres with concurrency %d\n", b.workerID, b.config.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < b.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *PgBroker) work(ctx context.Context) {
+	for {
+		jobs, err := b.claimer.ClaimJobs(ctx, b.workerID, b.config.LeaseDuration, 1)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[BROKER] Failed to claim jobs: %v\n", err)
+		}
+
+		if len(jobs) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.config.PollInterval):
+			}
+			continue
+		}
+
+		for _, job := range jobs {
+			b.runJob(ctx, job)
+		}
+	}
+}
+
+// runJob executes a claimed job and releases its lease with the outcome.
+func (b *PgBroker) runJob(ctx context.Context, job task.JobPayload) {
+	log.Printf("[BROKER] Processing job ID %s (attempt %d)...\n", job.ID, job.Attempts)
+
+	progress := newProgressReporter(ctx, b.claimer, job.ID, b.config.ProgressInterval)
+	jobCtx, result := task.WithResult(task.WithProgress(task.WithJob(ctx, job), progress))
+	jobType, ok := b.types.Lookup(job.JobType)
+	err := fmt.Errorf("%w: unknown job type %q", asynq.SkipRetry, job.JobType)
+	if ok {
+		// The lease is renewed while the handler runs, so the type's timeout may exceed LeaseDuration
+		timeoutCtx, cancel := context.WithTimeout(jobCtx, jobType.Timeout)
+		renewed := make(chan struct{})
+		go func() {
+			defer close(renewed)
+			b.keepLease(timeoutCtx, job.ID, cancel)
+		}()
+		err = b.handler.ProcessTask(timeoutCtx, asynq.NewTask(jobType.TaskName, job.Payload))
+		cancel()
+		<-renewed
+	}
+	progress.Flush()
+
+	// Claiming counted the attempt, the outcome only decides what comes next
+	policy := b.policies.For(job.JobType, job.Retry)
+	status, runAt := nextState(policy, job.Attempts, err, time.Now())
+	if status != "retrying" {
+		runAt = job.RunAt
+	}
+	var lastError string
+	if err != nil {
+		lastError = err.Error()
+		log.Printf("[BROKER] Job ID %s attempt %d failed, now %s: %v\n", job.ID, job.Attempts, status, err)
+	}
+
+	// Release the lease even when shutting down, so the job isn't stuck until it expires
+	if err := b.claimer.FinishClaimedJob(context.WithoutCancel(ctx), job.ID, b.workerID, status, runAt, lastError, result()); err != nil {
+		log.Printf("[ERROR] Failed to release job %s: %v\n", job.ID, err)
+		return
+	}
+	log.Printf("[BROKER] Job ID %s finished with status %s.\n", job.ID, status)
+}
+
+// keepLease renews the lease on a running job every third of LeaseDuration
+// until ctx is done. If the lease was lost, e.g. because the job was
+// cancelled, the job is stopped with lost.
+func (b *PgBroker) keepLease(ctx context.Context, jobID uuid.UUID, lost context.CancelFunc) {
+	ticker := time.NewTicker(b.config.LeaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := b.claimer.RenewJobLease(ctx, jobID, b.workerID, b.config.LeaseDuration)
+		var appErr *common.AppError
+		if errors.As(err, &appErr) && appErr.StatusCode == http.StatusNotFound {
+			log.Printf("[BROKER] Lost the lease on job ID %s, stopping it.\n", jobID)
+			lost()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[BROKER] Failed to renew the lease on job ID %s: %v\n", jobID, err)
+		}
+	}
+}
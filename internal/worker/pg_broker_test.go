@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/config"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// fakeClaimer records the lease renewals and outcomes of claimed jobs. Once
+// renewals leases were renewed, the lease is lost.
+type fakeClaimer struct {
+	mu       sync.Mutex
+	renewals int
+	renewed  int
+	statuses []string
+}
+
+func (c *fakeClaimer) SaveJobProgress(context.Context, uuid.UUID, int, string) error { return nil }
+
+func (c *fakeClaimer) ClaimJobs(context.Context, string, time.Duration, int) ([]task.JobPayload, error) {
+	return nil, nil
+}
+
+func (c *fakeClaimer) RenewJobLease(context.Context, uuid.UUID, string, time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.renewed == c.renewals {
+		return common.NewNotFoundError("Job lease was lost")
+	}
+	c.renewed++
+	return nil
+}
+
+func (c *fakeClaimer) FinishClaimedJob(_ context.Context, _ uuid.UUID, _ string, status string, _ time.Time, _ string, _ json.RawMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statuses = append(c.statuses, status)
+	return nil
+}
+
+// newTestBroker returns a broker whose lease lasts lease and whose test job
+// type runs handler for up to timeout.
+func newTestBroker(claimer *fakeClaimer, lease, timeout time.Duration, handler asynq.HandlerFunc) *PgBroker {
+	types := jobtype.NewRegistry()
+	types.Register(jobtype.Type{
+		Name:    testJobType,
+		Decode:  jobtype.JSON[struct{}](),
+		Handler: handler,
+		Timeout: timeout,
+	})
+	cfg := config.QueueConfig{LeaseDuration: lease, ProgressInterval: time.Second}
+	return NewPgBroker(claimer, types, handler, cfg, retry.Policies{Default: retry.DefaultPolicy})
+}
+
+func TestPgBrokerRenewsLeaseOfLongJob(t *testing.T) {
+	claimer := &fakeClaimer{renewals: 1000}
+	lease := 30 * time.Millisecond
+	broker := newTestBroker(claimer, lease, time.Minute, func(ctx context.Context, _ *asynq.Task) error {
+		// Outlives the lease many times over, the timeout of its type allows it
+		select {
+		case <-time.After(10 * lease):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	broker.runJob(context.Background(), task.JobPayload{ID: uuid.New(), JobType: testJobType, Attempts: 1})
+
+	if len(claimer.statuses) != 1 || claimer.statuses[0] != "completed" {
+		t.Errorf("statuses = %v, want [completed]", claimer.statuses)
+	}
+	if claimer.renewed < 3 {
+		t.Errorf("lease renewed %d times, want it renewed while the job ran", claimer.renewed)
+	}
+}
+
+func TestPgBrokerStopsJobOnLostLease(t *testing.T) {
+	claimer := &fakeClaimer{renewals: 1}
+	stopped := make(chan struct{})
+	broker := newTestBroker(claimer, 30*time.Millisecond, time.Minute, func(ctx context.Context, _ *asynq.Task) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		broker.runJob(context.Background(), task.JobPayload{ID: uuid.New(), JobType: testJobType, Attempts: 1})
+		close(done)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job kept running after its lease was lost")
+	}
+	<-done
+	if claimer.renewed != 1 {
+		t.Errorf("lease renewed %d times, want 1 before it was lost", claimer.renewed)
+	}
+}
@@ -0,0 +1,35 @@
+package priority
+
+import "time"
+
+// Values maps the priority stored in the jobs table to its heap value; a
+// lower value runs first.
+var Values = map[string]int{
+	"high":   1,
+	"medium": 2,
+	"low":    3,
+}
+
+// Valid reports whether name is a known priority.
+func Valid(name string) bool {
+	_, ok := Values[name]
+	return ok
+}
+
+// Effective returns the priority of a job that has been due since runAt:
+// it rises one level for every interval the job has waited, up to "high". A
+// zero interval disables aging.
+func Effective(name string, runAt time.Time, now time.Time, interval time.Duration) string {
+	value, ok := Values[name]
+	if !ok || interval <= 0 || !now.After(runAt) {
+		return name
+	}
+
+	value -= int(now.Sub(runAt) / interval)
+	for candidate, candidateValue := range Values {
+		if candidateValue == max(value, Values["high"]) {
+			return candidate
+		}
+	}
+	return name
+}
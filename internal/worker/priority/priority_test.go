@@ -0,0 +1,33 @@
+package priority
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffective(t *testing.T) {
+	runAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		priority string
+		waited   time.Duration
+		interval time.Duration
+		want     string
+	}{
+		{name: "aging disabled", priority: "low", waited: time.Hour, want: "low"},
+		{name: "not due yet", priority: "low", waited: -time.Hour, interval: time.Minute, want: "low"},
+		{name: "less than an interval", priority: "low", waited: 59 * time.Second, interval: time.Minute, want: "low"},
+		{name: "one interval", priority: "low", waited: time.Minute, interval: time.Minute, want: "medium"},
+		{name: "two intervals", priority: "low", waited: 2 * time.Minute, interval: time.Minute, want: "high"},
+		{name: "capped at high", priority: "low", waited: time.Hour, interval: time.Minute, want: "high"},
+		{name: "high stays high", priority: "high", waited: time.Hour, interval: time.Minute, want: "high"},
+		{name: "unknown priority", priority: "urgent", waited: time.Hour, interval: time.Minute, want: "urgent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Effective(tt.priority, runAt, runAt.Add(tt.waited), tt.interval); got != tt.want {
+				t.Errorf("Effective(%q) after %s = %q, want %q", tt.priority, tt.waited, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// Headers every http:request job sends along with its own.
+const (
+	HeaderJobID     = "X-Job-ID"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// WebhookSecrets looks up the key a user's requests are signed with.
+type WebhookSecrets interface {
+	WebhookSecret(context.Context, uuid.UUID) (string, error)
+}
+
+// HTTPProcessor sends the requests of http:request jobs.
+type HTTPProcessor struct {
+	Client  *http.Client
+	Secrets WebhookSecrets
+}
+
+func NewHTTPProcessor(client *http.Client, secrets WebhookSecrets) *HTTPProcessor {
+	return &HTTPProcessor{Client: client, Secrets: secrets}
+}
+
+// sharedNetwork is the carrier-grade NAT range, which clusters commonly hand
+// out to pods and services.
+var sharedNetwork = netip.MustParsePrefix("100.64.0.0/10")
+
+// ErrForbiddenAddress is returned when a request would reach an address of the
+// host or its networks.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// NewPublicClient returns a client that only connects to public addresses, so
+// jobs cannot reach the host, its cloud metadata service or cluster internal
+// services. The address is checked once resolved, which covers redirects and
+// DNS names pointing inwards too.
+func NewPublicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublic(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on behalf of the job, past the dialer's check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedNetwork.Contains(addr)
+}
+
+// Register adds the http:request job type to registry.
+func (p *HTTPProcessor) Register(registry *jobtype.Registry) {
+	registry.Register(jobtype.Type{
+		Name:    task.TaskHTTPRequest,
+		Decode:  jobtype.JSON[task.HTTPRequestPayload](),
+		Handler: p.HandleHTTPRequest,
+		// Requests time out on their own, this only bounds a stuck attempt
+		Timeout: task.MaxHTTPTimeout + 30*time.Second,
+	})
+}
+
+// Sign returns the signature of a request sent at timestamp: the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with secret. Receivers recompute
+// it from the X-Webhook-Timestamp header and the raw body.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HandleHTTPRequest sends the request of a job and stores the response as its
+// result. Server errors, 408 and 429 are retried, any other non-2xx response
+// fails the job for good.
+func (p *HTTPProcessor) HandleHTTPRequest(ctx context.Context, t *asynq.Task) error {
+	var payload task.HTTPRequestPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("%w: json.Unmarshal failed: %v", asynq.SkipRetry, err)
+	}
+	if err := payload.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+
+	timeout := time.Duration(payload.Timeout)
+	if timeout == 0 {
+		timeout = task.DefaultHTTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body := []byte(payload.Body)
+	req, err := http.NewRequestWithContext(ctx, payload.Method, payload.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+	for name, value := range payload.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Jobs of the system have no owner and go out unsigned
+	if job, ok := task.JobFromContext(ctx); ok && job.UserID != uuid.Nil {
+		secret, err := p.Secrets.WebhookSecret(ctx, job.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to load webhook secret: %w", err)
+		}
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderJobID, job.ID.String())
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		// Connection errors and timeouts are worth another attempt
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, task.MaxHTTPResultBody+1))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	result := task.HTTPResponseResult{
+		StatusCode:    resp.StatusCode,
+		Headers:       resp.Header,
+		Body:          string(respBody[:min(len(respBody), task.MaxHTTPResultBody)]),
+		BodyTruncated: len(respBody) > task.MaxHTTPResultBody,
+	}
+	if err := task.SetResult(ctx, result); err != nil {
+		return fmt.Errorf("failed to record response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("server responded %s", resp.Status)
+	default:
+		return fmt.Errorf("%w: server responded %s", asynq.SkipRetry, resp.Status)
+	}
+}
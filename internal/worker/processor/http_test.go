@@ -0,0 +1,208 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+type fakeSecrets map[uuid.UUID]string
+
+func (s fakeSecrets) WebhookSecret(_ context.Context, userID uuid.UUID) (string, error) {
+	secret, ok := s[userID]
+	if !ok {
+		return "", errors.New("no secret")
+	}
+	return secret, nil
+}
+
+// runRequest runs an http:request job sending method to url for job, and
+// returns the result the handler recorded and its error.
+func runRequest(t *testing.T, p *HTTPProcessor, job task.JobPayload, method, url string) (task.HTTPResponseResult, error) {
+	t.Helper()
+	payload, err := json.Marshal(task.HTTPRequestPayload{Method: method, URL: url, Body: `{"hello":"world"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, getResult := task.WithResult(task.WithJob(context.Background(), job))
+	handleErr := p.HandleHTTPRequest(ctx, asynq.NewTask(task.TaskHTTPRequest, payload))
+
+	var result task.HTTPResponseResult
+	if data := getResult(); data != nil {
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return result, handleErr
+}
+
+func TestHandleHTTPRequestSignsRequests(t *testing.T) {
+	userID := uuid.New()
+	job := task.JobPayload{ID: uuid.New(), UserID: userID}
+
+	var got *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	p := NewHTTPProcessor(server.Client(), fakeSecrets{userID: "s3cret"})
+	if _, err := runRequest(t, p, job, http.MethodPost, server.URL); err != nil {
+		t.Fatalf("HandleHTTPRequest() = %v, want nil", err)
+	}
+
+	if id := got.Header.Get(HeaderJobID); id != job.ID.String() {
+		t.Errorf("%s = %q, want %q", HeaderJobID, id, job.ID)
+	}
+	timestamp := got.Header.Get(HeaderTimestamp)
+	if timestamp == "" {
+		t.Fatalf("%s is missing", HeaderTimestamp)
+	}
+	if signature := got.Header.Get(HeaderSignature); signature != Sign("s3cret", timestamp, gotBody) {
+		t.Errorf("%s = %q does not verify against the body", HeaderSignature, signature)
+	}
+	if signature := got.Header.Get(HeaderSignature); signature == Sign("other", timestamp, gotBody) {
+		t.Errorf("%s verifies with another secret", HeaderSignature)
+	}
+}
+
+func TestHandleHTTPRequestLeavesSystemJobsUnsigned(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer server.Close()
+
+	p := NewHTTPProcessor(server.Client(), fakeSecrets{})
+	if _, err := runRequest(t, p, task.JobPayload{ID: uuid.New()}, http.MethodPost, server.URL); err != nil {
+		t.Fatalf("HandleHTTPRequest() = %v, want nil", err)
+	}
+	if signature := got.Header.Get(HeaderSignature); signature != "" {
+		t.Errorf("%s = %q, want none", HeaderSignature, signature)
+	}
+}
+
+func TestHandleHTTPRequestClassifiesResponses(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		wantRetry bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusNoContent},
+		{status: http.StatusInternalServerError, wantErr: true, wantRetry: true},
+		{status: http.StatusBadGateway, wantErr: true, wantRetry: true},
+		{status: http.StatusRequestTimeout, wantErr: true, wantRetry: true},
+		{status: http.StatusTooManyRequests, wantErr: true, wantRetry: true},
+		{status: http.StatusBadRequest, wantErr: true},
+		{status: http.StatusNotFound, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			p := NewHTTPProcessor(server.Client(), fakeSecrets{})
+			result, err := runRequest(t, p, task.JobPayload{ID: uuid.New()}, http.MethodGet, server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HandleHTTPRequest() = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && errors.Is(err, asynq.SkipRetry) == tt.wantRetry {
+				t.Errorf("HandleHTTPRequest() = %v, want retry %v", err, tt.wantRetry)
+			}
+			if result.StatusCode != tt.status {
+				t.Errorf("result status = %d, want %d", result.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestHandleHTTPRequestTruncatesBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int
+		wantTruncated bool
+	}{
+		{name: "fits", size: task.MaxHTTPResultBody},
+		{name: "too long", size: task.MaxHTTPResultBody + 10, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, strings.Repeat("a", tt.size))
+			}))
+			defer server.Close()
+
+			p := NewHTTPProcessor(server.Client(), fakeSecrets{})
+			result, err := runRequest(t, p, task.JobPayload{ID: uuid.New()}, http.MethodGet, server.URL)
+			if err != nil {
+				t.Fatalf("HandleHTTPRequest() = %v, want nil", err)
+			}
+			if want := min(tt.size, task.MaxHTTPResultBody); len(result.Body) != want {
+				t.Errorf("len(result body) = %d, want %d", len(result.Body), want)
+			}
+			if result.BodyTruncated != tt.wantTruncated {
+				t.Errorf("result body truncated = %v, want %v", result.BodyTruncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestPublicClientRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	p := NewHTTPProcessor(NewPublicClient(), fakeSecrets{})
+	_, err := runRequest(t, p, task.JobPayload{ID: uuid.New()}, http.MethodGet, server.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("HandleHTTPRequest() = %v, want %v", err, ErrForbiddenAddress)
+	}
+	if called {
+		t.Error("the request reached the server")
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"0.0.0.0", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package processor
+
+import (
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/Nezent/go-queue/internal/worker/task"
+)
+
+// Register adds the job types run by the processor to registry.
+func (p *TaskProcessor) Register(registry *jobtype.Registry) {
+	// Matches the 5 retries asynq gives the task with the Redis broker
+	verificationAttempts := 6
+	registry.Register(jobtype.Type{
+		Name:     task.TaskSendVerificationEmail,
+		Decode:   jobtype.JSON[task.SendVerificationEmailPayload](),
+		Handler:  p.HandleSendVerificationEmail,
+		Priority: "high",
+		Retry:    &retry.Override{MaxAttempts: &verificationAttempts},
+		Internal: true,
+	})
+	registry.Register(jobtype.Type{
+		Name:     task.JobTypeEmail,
+		TaskName: task.TaskSendJobEmail,
+		Decode:   jobtype.JSON[task.EmailPayload](),
+		Handler:  p.HandleSendJobEmail,
+	})
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobProgressStore persists the progress reports of running jobs.
+type JobProgressStore interface {
+	SaveJobProgress(context.Context, uuid.UUID, int, string) error
+}
+
+// progressReporter persists the progress reports of one job attempt. Reports
+// are saved at most once per interval, Flush saves the latest one left.
+type progressReporter struct {
+	ctx      context.Context
+	store    JobProgressStore
+	jobID    uuid.UUID
+	interval time.Duration
+
+	mu      sync.Mutex
+	percent int
+	message string
+	dirty   bool
+	savedAt time.Time
+}
+
+func newProgressReporter(ctx context.Context, store JobProgressStore, jobID uuid.UUID, interval time.Duration) *progressReporter {
+	// Saving must not fail because the handler's deadline passed
+	return &progressReporter{ctx: context.WithoutCancel(ctx), store: store, jobID: jobID, interval: interval}
+}
+
+func (r *progressReporter) Report(percent int, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.percent, r.message, r.dirty = percent, message, true
+	if time.Since(r.savedAt) >= r.interval {
+		r.save()
+	}
+}
+
+// Flush saves the latest report if it was throttled.
+func (r *progressReporter) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dirty {
+		r.save()
+	}
+}
+
+func (r *progressReporter) save() {
+	r.dirty = false
+	r.savedAt = time.Now()
+	if err := r.store.SaveJobProgress(r.ctx, r.jobID, r.percent, r.message); err != nil {
+		log.Printf("[PROGRESS] Failed to save progress of job %s: %v\n", r.jobID, err)
+	}
+}
@@ -1,8 +1,7 @@
 package worker
 
 import (
-	"github.com/Nezent/go-queue/internal/worker/processor"
-	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
 	"github.com/hibiken/asynq"
 )
 
@@ -13,11 +12,11 @@ func NewAsynqServer(redisOpt asynq.RedisClientOpt) *asynq.Server {
 	})
 }
 
-// Initializes and returns the task mux with handlers registered
-func NewServeMux(processor *processor.TaskProcessor) *asynq.ServeMux {
+// Initializes and returns the task mux with the handler of every registered job type
+func NewServeMux(types *jobtype.Registry) *asynq.ServeMux {
 	mux := asynq.NewServeMux()
-	mux.HandleFunc(task.TaskSendVerificationEmail, processor.HandleSendVerificationEmail)
-	mux.HandleFunc(task.TaskSendJobEmail, processor.HandleSendJobEmail)
-	// Register other task handlers here
+	for _, jobType := range types.Types() {
+		mux.HandleFunc(jobType.TaskName, jobType.Handler)
+	}
 	return mux
 }
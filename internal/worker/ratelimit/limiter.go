@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+// Limit is a token bucket: Rate jobs per second on average, with bursts of up
+// to Burst jobs.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Valid reports whether the bucket can ever let a job through.
+func (l Limit) Valid() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Config lists the configured limits. A job has to get past both the bucket
+// of its type and the bucket of its user; types and users without a limit are
+// not throttled on that side.
+type Config struct {
+	// Types limits dispatch per job type
+	Types map[string]Limit `json:"types"`
+	// Users limits dispatch of specific users
+	Users map[uuid.UUID]Limit `json:"users"`
+	// DefaultUser limits every user without an entry in Users
+	DefaultUser *Limit `json:"default_user,omitempty"`
+}
+
+// Empty reports whether no limit is configured at all.
+func (c Config) Empty() bool {
+	return len(c.Types) == 0 && len(c.Users) == 0 && c.DefaultUser == nil
+}
+
+// maxIdleUsers bounds the per-user buckets kept around; full buckets are
+// dropped past it, as a full bucket is the same as a fresh one.
+const maxIdleUsers = 10_000
+
+// Limiter throttles job dispatch with token buckets keyed by job type and by
+// user ID. It is safe for concurrent use.
+type Limiter struct {
+	mu     sync.Mutex
+	config Config
+	types  map[string]*rate.Limiter
+	users  map[uuid.UUID]*rate.Limiter
+}
+
+func NewLimiter(config Config) *Limiter {
+	return &Limiter{
+		config: config,
+		types:  map[string]*rate.Limiter{},
+		users:  map[uuid.UUID]*rate.Limiter{},
+	}
+}
+
+// Reserve books a dispatch slot for a job and returns the time it may be
+// dispatched at, now if it isn't throttled. The slot stays booked, so a job
+// deferred to that time must be dispatched without reserving again.
+func (l *Limiter) Reserve(jobType string, userID uuid.UUID, now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at := now
+	for _, bucket := range []*rate.Limiter{l.typeBucket(jobType), l.userBucket(userID, now)} {
+		if bucket == nil {
+			continue
+		}
+		reservation := bucket.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); now.Add(delay).After(at) {
+			at = now.Add(delay)
+		}
+	}
+	return at
+}
+
+func (l *Limiter) typeBucket(jobType string) *rate.Limiter {
+	if bucket, ok := l.types[jobType]; ok {
+		return bucket
+	}
+	limit, ok := l.config.Types[jobType]
+	if !ok {
+		return nil
+	}
+	bucket := rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+	l.types[jobType] = bucket
+	return bucket
+}
+
+func (l *Limiter) userBucket(userID uuid.UUID, now time.Time) *rate.Limiter {
+	if userID == uuid.Nil {
+		// System jobs such as verification emails have no user
+		return nil
+	}
+	if bucket, ok := l.users[userID]; ok {
+		return bucket
+	}
+
+	limit, ok := l.config.Users[userID]
+	if !ok {
+		if l.config.DefaultUser == nil {
+			return nil
+		}
+		limit = *l.config.DefaultUser
+	}
+
+	if len(l.users) >= maxIdleUsers {
+		for id, bucket := range l.users {
+			if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+				delete(l.users, id)
+			}
+		}
+	}
+	bucket := rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+	l.users[userID] = bucket
+	return bucket
+}
+
+// Bucket is the state of one token bucket.
+type Bucket struct {
+	Key    string  `json:"key"`
+	Rate   float64 `json:"rate"`
+	Burst  int     `json:"burst"`
+	Tokens float64 `json:"tokens"` // negative while jobs are deferred
+}
+
+// State is the state of every bucket in use.
+type State struct {
+	Types []Bucket `json:"types"`
+	Users []Bucket `json:"users"`
+}
+
+// State returns the buckets in use at now.
+func (l *Limiter) State(now time.Time) State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := State{Types: []Bucket{}, Users: []Bucket{}}
+	for jobType, bucket := range l.types {
+		state.Types = append(state.Types, newBucket(jobType, bucket, now))
+	}
+	for userID, bucket := range l.users {
+		state.Users = append(state.Users, newBucket(userID.String(), bucket, now))
+	}
+
+	sort.Slice(state.Types, func(i, j int) bool { return state.Types[i].Key < state.Types[j].Key })
+	sort.Slice(state.Users, func(i, j int) bool { return state.Users[i].Key < state.Users[j].Key })
+	return state
+}
+
+// Advance returns the state elapsed later, with every bucket refilled at its
+// rate up to its burst.
+func (s State) Advance(elapsed time.Duration) State {
+	advance := func(buckets []Bucket) []Bucket {
+		advanced := make([]Bucket, len(buckets))
+		for i, bucket := range buckets {
+			bucket.Tokens = min(bucket.Tokens+bucket.Rate*elapsed.Seconds(), float64(bucket.Burst))
+			advanced[i] = bucket
+		}
+		return advanced
+	}
+	return State{Types: advance(s.Types), Users: advance(s.Users)}
+}
+
+func newBucket(key string, bucket *rate.Limiter, now time.Time) Bucket {
+	return Bucket{
+		Key:    key,
+		Rate:   float64(bucket.Limit()),
+		Burst:  bucket.Burst(),
+		Tokens: bucket.TokensAt(now),
+	}
+}
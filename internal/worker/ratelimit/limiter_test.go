@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReserveSpendsBurst(t *testing.T) {
+	user := uuid.New()
+	tests := []struct {
+		name    string
+		config  Config
+		jobType string
+	}{
+		{name: "type bucket", config: Config{Types: map[string]Limit{"email": {Rate: 1, Burst: 3}}}, jobType: "email"},
+		{name: "user bucket", config: Config{Users: map[uuid.UUID]Limit{user: {Rate: 1, Burst: 3}}}, jobType: "other"},
+		{name: "default user bucket", config: Config{DefaultUser: &Limit{Rate: 1, Burst: 3}}, jobType: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.config)
+			now := time.Now()
+
+			for i := 0; i < 3; i++ {
+				if at := l.Reserve(tt.jobType, user, now); !at.Equal(now) {
+					t.Fatalf("Reserve() #%d within the burst = now+%s, want now", i+1, at.Sub(now))
+				}
+			}
+			// Past the burst every job waits one more second at a rate of 1/s
+			for i := 1; i <= 3; i++ {
+				if at, want := l.Reserve(tt.jobType, user, now), now.Add(time.Duration(i)*time.Second); !at.Equal(want) {
+					t.Errorf("Reserve() #%d past the burst = now+%s, want now+%s", i, at.Sub(now), want.Sub(now))
+				}
+			}
+
+			// The bucket refills at its rate
+			later := now.Add(10 * time.Second)
+			if at := l.Reserve(tt.jobType, user, later); !at.Equal(later) {
+				t.Errorf("Reserve() once refilled = later+%s, want later", at.Sub(later))
+			}
+		})
+	}
+}
+
+func TestReserveTakesSlowestBucket(t *testing.T) {
+	user := uuid.New()
+	l := NewLimiter(Config{
+		Types: map[string]Limit{"email": {Rate: 1, Burst: 1}},
+		Users: map[uuid.UUID]Limit{user: {Rate: 0.5, Burst: 1}},
+	})
+	now := time.Now()
+
+	l.Reserve("email", user, now)
+	if at, want := l.Reserve("email", user, now), now.Add(2*time.Second); !at.Equal(want) {
+		t.Errorf("Reserve() = now+%s, want now+%s from the user bucket", at.Sub(now), want.Sub(now))
+	}
+}
+
+func TestReserveLeavesUnlimitedJobsAlone(t *testing.T) {
+	limited := uuid.New()
+	config := Config{
+		Types: map[string]Limit{"email": {Rate: 1, Burst: 1}},
+		Users: map[uuid.UUID]Limit{limited: {Rate: 1, Burst: 1}},
+	}
+	tests := []struct {
+		name    string
+		jobType string
+		userID  uuid.UUID
+	}{
+		{name: "unknown type and user", jobType: "other", userID: uuid.New()},
+		{name: "unknown type of a system job", jobType: "other", userID: uuid.Nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(config)
+			now := time.Now()
+
+			// Spend the configured buckets, which must not hold up anything else
+			l.Reserve("email", limited, now)
+			for i := 0; i < 100; i++ {
+				if at := l.Reserve(tt.jobType, tt.userID, now); !at.Equal(now) {
+					t.Fatalf("Reserve() #%d = now+%s, want now", i+1, at.Sub(now))
+				}
+			}
+			if state := l.State(now); len(state.Types) != 1 || len(state.Users) != 1 {
+				t.Errorf("State() = %+v, want only the configured buckets", state)
+			}
+		})
+	}
+}
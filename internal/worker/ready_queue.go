@@ -0,0 +1,192 @@
+package worker
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/worker/priority"
+	"github.com/google/uuid"
+)
+
+// ReadyQueue holds the jobs that are due, in the order they are dispatched.
+// Jobs keep their heap index in it, so they can be removed cheaply.
+type ReadyQueue interface {
+	Push(*JobItem)
+	// Pop removes the job to dispatch next, or returns nil if there is none
+	Pop() *JobItem
+	Remove(*JobItem)
+	Len() int
+	Clear()
+}
+
+// readyHeap orders due jobs like JobPriorityQueue, except that every priority
+// level below "high" pushes a job back by the aging interval. A job waiting
+// for long therefore overtakes fresher jobs of higher priority: its effective
+// priority rises one level per interval it has been due. As every due job
+// ages at the same pace, the order never changes over time.
+type readyHeap struct {
+	JobPriorityQueue
+}
+
+func (h readyHeap) Less(i, j int) bool {
+	if !h.JobPriorityQueue[i].agedRunAt.Equal(h.JobPriorityQueue[j].agedRunAt) {
+		return h.JobPriorityQueue[i].agedRunAt.Before(h.JobPriorityQueue[j].agedRunAt)
+	}
+	return h.JobPriorityQueue[i].Priority < h.JobPriorityQueue[j].Priority
+}
+
+// age sets the key readyHeap orders job by. Without aging it is the run time.
+func age(job *JobItem, aging time.Duration) {
+	job.agedRunAt = job.RunAt.Add(time.Duration(job.Priority-priority.Values["high"]) * aging)
+}
+
+// fifoQueue dispatches due jobs in heap order, whoever they belong to.
+type fifoQueue struct {
+	jobs  readyHeap
+	aging time.Duration
+}
+
+// NewFIFOQueue builds a queue aging due jobs by one priority level per aging
+// interval; zero disables aging.
+func NewFIFOQueue(aging time.Duration) ReadyQueue {
+	return &fifoQueue{aging: aging}
+}
+
+func (q *fifoQueue) Push(job *JobItem) {
+	age(job, q.aging)
+	heap.Push(&q.jobs, job)
+}
+
+func (q *fifoQueue) Pop() *JobItem {
+	if q.jobs.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(&q.jobs).(*JobItem)
+}
+
+func (q *fifoQueue) Remove(job *JobItem) { heap.Remove(&q.jobs, job.index) }
+
+func (q *fifoQueue) Len() int { return q.jobs.Len() }
+
+func (q *fifoQueue) Clear() { q.jobs = readyHeap{} }
+
+// fairQueue shares dispatch between users by deficit round-robin: every turn
+// a user may dispatch as many jobs as its weight, so a user with a large
+// backlog can't starve the others. Each user's jobs keep the heap order.
+type fairQueue struct {
+	weights       map[uuid.UUID]int
+	defaultWeight int
+	aging         time.Duration
+
+	users   map[uuid.UUID]*readyHeap
+	deficit map[uuid.UUID]int
+	// ring lists the users with due jobs; ring[cursor] is the user whose turn it is
+	ring   []uuid.UUID
+	cursor int
+	size   int
+}
+
+// NewFairQueue builds a fair queue aging due jobs like NewFIFOQueue. Users
+// without a weight get weight 1.
+func NewFairQueue(weights map[uuid.UUID]int, aging time.Duration) ReadyQueue {
+	q := &fairQueue{weights: weights, defaultWeight: 1, aging: aging}
+	q.Clear()
+	return q
+}
+
+func (q *fairQueue) weight(userID uuid.UUID) int {
+	if weight, ok := q.weights[userID]; ok {
+		return weight
+	}
+	return q.defaultWeight
+}
+
+func (q *fairQueue) Push(job *JobItem) {
+	jobs, ok := q.users[job.UserID]
+	if !ok {
+		jobs = &readyHeap{}
+		q.users[job.UserID] = jobs
+		q.ring = append(q.ring, job.UserID)
+		if len(q.ring) == 1 {
+			// The only user starts its turn right away
+			q.cursor = 0
+			q.deficit[job.UserID] = q.weight(job.UserID)
+		}
+	}
+	age(job, q.aging)
+	heap.Push(jobs, job)
+	q.size++
+}
+
+func (q *fairQueue) Pop() *JobItem {
+	if q.size == 0 {
+		return nil
+	}
+
+	// Move on until a user has credit left; weights are at least 1, so one lap is enough
+	for q.deficit[q.ring[q.cursor]] < 1 {
+		q.advance()
+	}
+
+	userID := q.ring[q.cursor]
+	q.deficit[userID]--
+	jobs := q.users[userID]
+	job := heap.Pop(jobs).(*JobItem)
+	q.size--
+	if jobs.Len() == 0 {
+		q.drop(userID)
+	}
+	return job
+}
+
+func (q *fairQueue) Remove(job *JobItem) {
+	jobs := q.users[job.UserID]
+	heap.Remove(jobs, job.index)
+	q.size--
+	if jobs.Len() == 0 {
+		q.drop(job.UserID)
+	}
+}
+
+func (q *fairQueue) Len() int { return q.size }
+
+func (q *fairQueue) Clear() {
+	q.users = map[uuid.UUID]*readyHeap{}
+	q.deficit = map[uuid.UUID]int{}
+	q.ring = nil
+	q.cursor = 0
+	q.size = 0
+}
+
+// advance hands the turn to the next user and credits it with its weight.
+func (q *fairQueue) advance() {
+	q.cursor = (q.cursor + 1) % len(q.ring)
+	userID := q.ring[q.cursor]
+	q.deficit[userID] += q.weight(userID)
+}
+
+// drop takes a user without due jobs out of the ring. Like in deficit
+// round-robin, an idle user doesn't keep its unused credit.
+func (q *fairQueue) drop(userID uuid.UUID) {
+	delete(q.users, userID)
+	delete(q.deficit, userID)
+
+	for i, id := range q.ring {
+		if id != userID {
+			continue
+		}
+		q.ring = append(q.ring[:i], q.ring[i+1:]...)
+		if len(q.ring) == 0 {
+			q.cursor = 0
+			return
+		}
+		if i < q.cursor {
+			q.cursor--
+		} else if i == q.cursor {
+			// The turn passes to the user that took its place
+			q.cursor %= len(q.ring)
+			q.deficit[q.ring[q.cursor]] += q.weight(q.ring[q.cursor])
+		}
+		return
+	}
+}
@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testUsers names the users of the fair queue tests.
+var testUsers = map[string]uuid.UUID{
+	"A": uuid.New(),
+	"B": uuid.New(),
+	"C": uuid.New(),
+}
+
+func newUserJob(user string, runAt time.Time) *JobItem {
+	job := newTestJob(runAt)
+	job.UserID = testUsers[user]
+	job.Priority = 2
+	return job
+}
+
+func TestFairQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]int
+		// steps are "+X" to push a job of user X, "-X" to pop one expecting a job of X
+		steps string
+	}{
+		{
+			name:  "equal weights alternate",
+			steps: "+A +A +A +B +B +B -A -B -A -B -A -B",
+		},
+		{
+			name:    "weights share dispatch",
+			weights: map[string]int{"A": 2},
+			steps:   "+A +A +A +A +B +B -A -A -B -A -A -B",
+		},
+		{
+			name:    "a heavy user keeps its turn until its credit is spent",
+			weights: map[string]int{"A": 3},
+			steps:   "+A +A +A +A +A +A +B +B -A -A -A -B -A -A -A -B",
+		},
+		{
+			name:  "a drained user leaves the ring",
+			steps: "+A +B +B +B -A -B -B -B",
+		},
+		{
+			name:    "a drained user loses its unused credit",
+			weights: map[string]int{"A": 3},
+			steps:   "+A +B -A +A +A +A +A +C +C -B -A -A -A -C -A -C",
+		},
+		{
+			name:  "a user joining gets a turn in the next round",
+			steps: "+A +A +A +A -A +B -B -A +C -C -A -A",
+		},
+		{
+			name:  "three users share evenly",
+			steps: "+A +A +B +B +C +C -A -B -C -A -B -C",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights := map[uuid.UUID]int{}
+			for user, weight := range tt.weights {
+				weights[testUsers[user]] = weight
+			}
+			q := NewFairQueue(weights, 0)
+			now := time.Now()
+
+			for i, step := range strings.Fields(tt.steps) {
+				user := step[1:]
+				if step[0] == '+' {
+					// Later jobs are due later, so each user's jobs pop in push order
+					q.Push(newUserJob(user, now.Add(time.Duration(i)*time.Second)))
+					continue
+				}
+				job := q.Pop()
+				if job == nil {
+					t.Fatalf("step %d: Pop() = nil, want a job of %s", i, user)
+				}
+				if job.UserID != testUsers[user] {
+					t.Fatalf("step %d: Pop() returned a job of %s, want %s", i, userName(job.UserID), user)
+				}
+			}
+			if got := q.Len(); got != 0 {
+				t.Errorf("Len() = %d after the steps, want 0", got)
+			}
+		})
+	}
+}
+
+func TestFairQueueDoesNotStarveLightUser(t *testing.T) {
+	for _, weight := range []int{1, 5} {
+		q := NewFairQueue(map[uuid.UUID]int{testUsers["A"]: weight}, 0)
+		now := time.Now()
+		for i := 0; i < 100; i++ {
+			q.Push(newUserJob("A", now))
+		}
+		for i := 0; i < 10; i++ {
+			q.Pop()
+		}
+
+		// However large A's backlog, B waits at most for A to spend one turn
+		q.Push(newUserJob("B", now.Add(time.Hour)))
+		popped := 0
+		for {
+			job := q.Pop()
+			if job == nil {
+				t.Fatalf("weight %d: B's job was never popped", weight)
+			}
+			popped++
+			if job.UserID == testUsers["B"] {
+				break
+			}
+		}
+		if popped > weight+1 {
+			t.Errorf("weight %d: B's job popped after %d pops, want at most %d", weight, popped, weight+1)
+		}
+	}
+}
+
+func TestFairQueueRemove(t *testing.T) {
+	q := NewFairQueue(nil, 0)
+	now := time.Now()
+
+	a := newUserJob("A", now)
+	q.Push(a)
+	q.Push(newUserJob("B", now))
+
+	// Removing the last job of the user whose turn it is passes the turn on
+	q.Remove(a)
+	if job := q.Pop(); job == nil || job.UserID != testUsers["B"] {
+		t.Fatalf("Pop() = %v, want B's job", job)
+	}
+	if job := q.Pop(); job != nil {
+		t.Errorf("Pop() = %v, want nil", job)
+	}
+}
+
+func userName(userID uuid.UUID) string {
+	for name, id := range testUsers {
+		if id == userID {
+			return name
+		}
+	}
+	return userID.String()
+}
+
+func TestFIFOQueueAging(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		// lowWaited is how long the low priority job has been due when a fresh high priority one arrives
+		lowWaited time.Duration
+		aging     time.Duration
+		wantFirst string
+	}{
+		// Without aging due jobs go by run time, priority only breaks ties
+		{name: "aging disabled", lowWaited: time.Hour, wantFirst: "low"},
+		{name: "aging disabled, same run time", wantFirst: "high"},
+		{name: "not aged enough", lowWaited: time.Minute, aging: time.Minute, wantFirst: "high"},
+		{name: "aged to high", lowWaited: 2 * time.Minute, aging: time.Minute, wantFirst: "high"},
+		{name: "aged past the fresh job", lowWaited: 3 * time.Minute, aging: time.Minute, wantFirst: "low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low := newTestJob(now.Add(-tt.lowWaited))
+			low.Priority = 3
+			high := newTestJob(now)
+			high.Priority = 1
+
+			q := NewFIFOQueue(tt.aging)
+			q.Push(high)
+			q.Push(low)
+
+			want := high
+			if tt.wantFirst == "low" {
+				want = low
+			}
+			if got := q.Pop(); got != want {
+				t.Errorf("Pop() returned the %s priority job first, want the %s one", priorityName(got), tt.wantFirst)
+			}
+		})
+	}
+}
+
+func priorityName(job *JobItem) string {
+	if job.Priority == 3 {
+		return "low"
+	}
+	return "high"
+}
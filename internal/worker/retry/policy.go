@@ -0,0 +1,154 @@
+package retry
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes JSON as "30s", "5m", ...
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Policy decides how often a failed job is retried and how long to wait in between.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, the first one included
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// BaseDelay is the delay after the first failed attempt
+	BaseDelay Duration `json:"base_delay,omitempty"`
+	// Multiplier grows the delay after every further failed attempt
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// MaxDelay caps the grown delay, zero leaves it uncapped
+	MaxDelay Duration `json:"max_delay,omitempty"`
+	// Jitter randomizes the delay by up to this fraction, e.g. 0.2 for ±20%
+	Jitter float64 `json:"jitter,omitempty"`
+}
+
+// Override changes the fields of a policy it sets; nil fields are inherited.
+// A set field applies even if it is zero, e.g. to turn jitter off.
+type Override struct {
+	MaxAttempts *int      `json:"max_attempts,omitempty"`
+	BaseDelay   *Duration `json:"base_delay,omitempty"`
+	Multiplier  *float64  `json:"multiplier,omitempty"`
+	MaxDelay    *Duration `json:"max_delay,omitempty"`
+	Jitter      *float64  `json:"jitter,omitempty"`
+}
+
+// DefaultPolicy applies to job types without a configured policy.
+var DefaultPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   Duration(time.Minute),
+	Multiplier:  2,
+	MaxDelay:    Duration(time.Hour),
+	Jitter:      0.1,
+}
+
+// Exhausted reports whether a job that already ran attempts times may not run again.
+func (p Policy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// Backoff returns how long to wait before the attempt following attempt.
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(max(attempt-1, 0)))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// Merge returns p with every field set by override applied on top.
+func (p Policy) Merge(override *Override) Policy {
+	if override == nil {
+		return p
+	}
+	if override.MaxAttempts != nil {
+		p.MaxAttempts = *override.MaxAttempts
+	}
+	if override.BaseDelay != nil {
+		p.BaseDelay = *override.BaseDelay
+	}
+	if override.Multiplier != nil {
+		p.Multiplier = *override.Multiplier
+	}
+	if override.MaxDelay != nil {
+		p.MaxDelay = *override.MaxDelay
+	}
+	if override.Jitter != nil {
+		p.Jitter = *override.Jitter
+	}
+	return p
+}
+
+// Merge returns o with every field set by override applied on top.
+func (o Override) Merge(override *Override) Override {
+	if override == nil {
+		return o
+	}
+	if override.MaxAttempts != nil {
+		o.MaxAttempts = override.MaxAttempts
+	}
+	if override.BaseDelay != nil {
+		o.BaseDelay = override.BaseDelay
+	}
+	if override.Multiplier != nil {
+		o.Multiplier = override.Multiplier
+	}
+	if override.MaxDelay != nil {
+		o.MaxDelay = override.MaxDelay
+	}
+	if override.Jitter != nil {
+		o.Jitter = override.Jitter
+	}
+	return o
+}
+
+// Validate reports a problem with a user supplied override, or "" if it is usable.
+func (o Override) Validate() string {
+	switch {
+	case o.MaxAttempts != nil && *o.MaxAttempts < 1:
+		return "max_attempts must be at least 1"
+	case o.BaseDelay != nil && *o.BaseDelay < 0, o.MaxDelay != nil && *o.MaxDelay < 0:
+		return "delays must not be negative"
+	case o.Multiplier != nil && *o.Multiplier < 1:
+		return "multiplier must be at least 1"
+	case o.Jitter != nil && (*o.Jitter < 0 || *o.Jitter > 1):
+		return "jitter must be between 0 and 1"
+	}
+	return ""
+}
+
+// Policies resolves the retry policy of a job from its type and per-job override.
+type Policies struct {
+	Default Policy
+	Types   map[string]Override
+}
+
+// For returns the policy for jobType with the per-job override applied.
+func (ps Policies) For(jobType string, override *Override) Policy {
+	policy := ps.Default
+	if typePolicy, ok := ps.Types[jobType]; ok {
+		policy = policy.Merge(&typePolicy)
+	}
+	return policy.Merge(override)
+}
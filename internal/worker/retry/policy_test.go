@@ -0,0 +1,144 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{BaseDelay: Duration(time.Minute), Multiplier: 2, MaxDelay: Duration(10 * time.Minute)}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Minute},
+		{attempt: 1, want: time.Minute},
+		{attempt: 2, want: 2 * time.Minute},
+		{attempt: 4, want: 8 * time.Minute},
+		{attempt: 5, want: 10 * time.Minute},
+		{attempt: 50, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+
+	uncapped := policy
+	uncapped.MaxDelay = 0
+	if got, want := uncapped.Backoff(6), 32*time.Minute; got != want {
+		t.Errorf("Backoff(6) without MaxDelay = %s, want %s", got, want)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := Policy{BaseDelay: Duration(time.Minute), Multiplier: 2, MaxDelay: Duration(4 * time.Minute), Jitter: 0.2}
+	tests := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{attempt: 1, delay: time.Minute},
+		// The jitter applies to the capped delay, so it may exceed MaxDelay by the jitter
+		{attempt: 10, delay: 4 * time.Minute},
+	}
+	for _, tt := range tests {
+		low, high := tt.delay*8/10, tt.delay*12/10
+		varied := false
+		for i := 0; i < 1000; i++ {
+			got := policy.Backoff(tt.attempt)
+			if got < low || got > high {
+				t.Fatalf("Backoff(%d) = %s, want within [%s, %s]", tt.attempt, got, low, high)
+			}
+			varied = varied || got != tt.delay
+		}
+		if !varied {
+			t.Errorf("Backoff(%d) always returned %s, want it jittered", tt.attempt, tt.delay)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	attempts, jitter, maxDelay := 5, 0.0, Duration(0)
+
+	tests := []struct {
+		name     string
+		override *Override
+		want     Policy
+	}{
+		{name: "nil override", want: DefaultPolicy},
+		{name: "nil fields are inherited", override: &Override{}, want: DefaultPolicy},
+		{
+			name:     "set fields apply",
+			override: &Override{MaxAttempts: &attempts},
+			want:     Policy{MaxAttempts: 5, BaseDelay: DefaultPolicy.BaseDelay, Multiplier: 2, MaxDelay: DefaultPolicy.MaxDelay, Jitter: 0.1},
+		},
+		{
+			name:     "zero turns jitter off",
+			override: &Override{Jitter: &jitter},
+			want:     Policy{MaxAttempts: 3, BaseDelay: DefaultPolicy.BaseDelay, Multiplier: 2, MaxDelay: DefaultPolicy.MaxDelay},
+		},
+		{
+			name:     "zero removes the cap",
+			override: &Override{MaxDelay: &maxDelay},
+			want:     Policy{MaxAttempts: 3, BaseDelay: DefaultPolicy.BaseDelay, Multiplier: 2, Jitter: 0.1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultPolicy.Merge(tt.override); got != tt.want {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoliciesFor(t *testing.T) {
+	typeAttempts, jobAttempts, jobJitter := 10, 2, 0.0
+	policies := Policies{
+		Default: DefaultPolicy,
+		Types:   map[string]Override{"email": {MaxAttempts: &typeAttempts}},
+	}
+
+	if got := policies.For("other", nil); got != DefaultPolicy {
+		t.Errorf("For() of an unconfigured type = %+v, want the default", got)
+	}
+	if got := policies.For("email", nil).MaxAttempts; got != typeAttempts {
+		t.Errorf("For() of a configured type has MaxAttempts %d, want %d", got, typeAttempts)
+	}
+
+	got := policies.For("email", &Override{MaxAttempts: &jobAttempts, Jitter: &jobJitter})
+	if got.MaxAttempts != jobAttempts || got.Jitter != 0 {
+		t.Errorf("For() with a job override = %+v, want MaxAttempts %d without jitter", got, jobAttempts)
+	}
+	for i := 0; i < 100; i++ {
+		if delay := got.Backoff(1); delay != time.Minute {
+			t.Fatalf("Backoff(1) = %s with jitter turned off, want %s", delay, time.Minute)
+		}
+	}
+}
+
+func TestOverrideValidate(t *testing.T) {
+	zero, one, negative := 0, 1, Duration(-time.Second)
+	low, high, fraction := 0.5, 1.5, 0.0
+
+	tests := []struct {
+		name     string
+		override Override
+		wantErr  bool
+	}{
+		{name: "empty", override: Override{}},
+		{name: "valid", override: Override{MaxAttempts: &one, Jitter: &fraction}},
+		{name: "no attempts", override: Override{MaxAttempts: &zero}, wantErr: true},
+		{name: "negative delay", override: Override{BaseDelay: &negative}, wantErr: true},
+		{name: "negative max delay", override: Override{MaxDelay: &negative}, wantErr: true},
+		{name: "shrinking multiplier", override: Override{Multiplier: &low}, wantErr: true},
+		{name: "jitter above 1", override: Override{Jitter: &high}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := tt.override.Validate(); (msg != "") != tt.wantErr {
+				t.Errorf("Validate() = %q, want error %v", msg, tt.wantErr)
+			}
+		})
+	}
+}
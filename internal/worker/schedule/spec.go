@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/robfig/cron/v3"
+)
+
+// Catch-up policies decide what happens to occurrences missed while no
+// scheduler was running.
+const (
+	// CatchUpSkip drops missed occurrences and only runs those still within the misfire grace.
+	CatchUpSkip = "skip"
+	// CatchUpOnce runs the latest missed occurrence a single time.
+	CatchUpOnce = "once"
+	// CatchUpAll runs every missed occurrence, up to MaxCatchUpRuns.
+	CatchUpAll = "all"
+)
+
+// MaxCatchUpRuns bounds the occurrences materialised at once, so a schedule
+// that was down for long doesn't flood the queue.
+const MaxCatchUpRuns = 1000
+
+// ValidCatchUp reports whether policy is a known catch-up policy.
+func ValidCatchUp(policy string) bool {
+	switch policy {
+	case CatchUpSkip, CatchUpOnce, CatchUpAll:
+		return true
+	}
+	return false
+}
+
+// Spec is a parsed cron expression evaluated in a time zone.
+type Spec struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// Parse parses a standard five-field cron expression (or a descriptor such as
+// "@daily") in the given IANA time zone. An empty zone means common.DhakaTZ.
+func Parse(expr string, timezone string) (*Spec, error) {
+	location := common.DhakaTZ
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", timezone)
+		}
+		location = loc
+	}
+
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron spec: %w", err)
+	}
+	return &Spec{schedule: schedule, location: location}, nil
+}
+
+// Next returns the first occurrence strictly after t.
+func (s *Spec) Next(t time.Time) time.Time {
+	return s.schedule.Next(t.In(s.location))
+}
+
+// Due returns the occurrences to run for a schedule whose next occurrence was
+// due at next, filtered by the catch-up policy, and the occurrence following now.
+func (s *Spec) Due(next time.Time, now time.Time, catchUp string, grace time.Duration) ([]time.Time, time.Time) {
+	var missed []time.Time
+	for occurrence := next; !occurrence.After(now); occurrence = s.Next(occurrence) {
+		if occurrence.IsZero() {
+			// The expression has no further occurrence
+			break
+		}
+		missed = append(missed, occurrence)
+		if catchUp == CatchUpAll && len(missed) == MaxCatchUpRuns {
+			break
+		}
+		if catchUp != CatchUpAll && len(missed) > 1 {
+			// Only the latest occurrence matters to the other policies
+			missed = missed[1:]
+		}
+	}
+
+	following := s.Next(now)
+	if len(missed) == 0 {
+		return nil, following
+	}
+
+	switch catchUp {
+	case CatchUpAll, CatchUpOnce:
+		return missed, following
+	default:
+		latest := missed[len(missed)-1]
+		if now.Sub(latest) > grace {
+			return nil, following
+		}
+		return []time.Time{latest}, following
+	}
+}
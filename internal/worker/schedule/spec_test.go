@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestSpecDue(t *testing.T) {
+	spec, err := Parse("*/5 * * * *", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		next          time.Time
+		now           time.Time
+		catchUp       string
+		grace         time.Duration
+		want          []time.Time
+		wantFollowing time.Time
+	}{
+		{
+			name: "nothing due", next: at(12, 20), now: at(12, 17), catchUp: CatchUpAll,
+			want: nil, wantFollowing: at(12, 20),
+		},
+		{
+			name: "due right now", next: at(12, 15), now: at(12, 15), catchUp: CatchUpSkip,
+			want: []time.Time{at(12, 15)}, wantFollowing: at(12, 20),
+		},
+		{
+			name: "all runs every missed tick", next: at(12, 0), now: at(12, 17), catchUp: CatchUpAll,
+			want: []time.Time{at(12, 0), at(12, 5), at(12, 10), at(12, 15)}, wantFollowing: at(12, 20),
+		},
+		{
+			name: "once runs the latest missed tick", next: at(12, 0), now: at(12, 17), catchUp: CatchUpOnce,
+			want: []time.Time{at(12, 15)}, wantFollowing: at(12, 20),
+		},
+		{
+			name: "once runs a tick however late", next: at(8, 0), now: at(12, 17), catchUp: CatchUpOnce,
+			want: []time.Time{at(12, 15)}, wantFollowing: at(12, 20),
+		},
+		{
+			name: "skip runs the latest tick within the grace", next: at(12, 0), now: at(12, 17), catchUp: CatchUpSkip, grace: 5 * time.Minute,
+			want: []time.Time{at(12, 15)}, wantFollowing: at(12, 20),
+		},
+		{
+			name: "skip drops ticks past the grace", next: at(12, 0), now: at(12, 17), catchUp: CatchUpSkip, grace: time.Minute,
+			want: nil, wantFollowing: at(12, 20),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, following := spec.Due(tt.next, tt.now, tt.catchUp, tt.grace)
+			if !slices.EqualFunc(got, tt.want, time.Time.Equal) {
+				t.Errorf("Due() occurrences = %v, want %v", got, tt.want)
+			}
+			if !following.Equal(tt.wantFollowing) {
+				t.Errorf("Due() following = %v, want %v", following, tt.wantFollowing)
+			}
+		})
+	}
+}
+
+func TestSpecDueCapsCatchUp(t *testing.T) {
+	spec, err := Parse("* * * * *", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := at(12, 0)
+	got, following := spec.Due(now.Add(-2*MaxCatchUpRuns*time.Minute), now, CatchUpAll, 0)
+	if len(got) != MaxCatchUpRuns {
+		t.Errorf("Due() returned %d occurrences, want %d", len(got), MaxCatchUpRuns)
+	}
+	if !following.Equal(now.Add(time.Minute)) {
+		t.Errorf("Due() following = %v, want %v", following, now.Add(time.Minute))
+	}
+}
+
+func TestSpecNextInTimeZone(t *testing.T) {
+	spec, err := Parse("0 9 * * *", "Asia/Dhaka")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 09:00 in Dhaka (UTC+6) is 03:00 UTC
+	if got := spec.Next(at(0, 0)); !got.Equal(at(3, 0)) {
+		t.Errorf("Next() = %v, want %v", got, at(3, 0))
+	}
+}
+
+func TestParseRejectsInvalidSpecs(t *testing.T) {
+	if _, err := Parse("not a cron spec", "UTC"); err == nil {
+		t.Error("Parse() of an invalid expression succeeded")
+	}
+	if _, err := Parse("@daily", "Mars/Olympus_Mons"); err == nil {
+		t.Error("Parse() with an unknown time zone succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/config"
+	"github.com/Nezent/go-queue/internal/domain"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/Nezent/go-queue/internal/worker/schedule"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// scheduleBatchSize bounds the schedules fired in one transaction.
+const scheduleBatchSize = 100
+
+// ScheduleSource locks due schedules and records the jobs they fired. Both
+// calls run in the transaction found in their context.
+type ScheduleSource interface {
+	ClaimDueSchedules(context.Context, int) ([]domain.JobSchedule, *common.AppError)
+	MaterializeSchedule(context.Context, domain.JobSchedule, []time.Time, time.Time) *common.AppError
+}
+
+// ScheduleRunner materialises the occurrences of cron schedules into jobs as
+// they fall due. The jobs are then picked up like any other pending job.
+type ScheduleRunner struct {
+	db        *pgxpool.Pool
+	schedules ScheduleSource
+	interval  time.Duration
+	grace     time.Duration
+}
+
+func NewScheduleRunner(db *pgxpool.Pool, schedules ScheduleSource, cfg config.QueueConfig) *ScheduleRunner {
+	return &ScheduleRunner{
+		db:        db,
+		schedules: schedules,
+		interval:  cfg.SchedulePollInterval,
+		grace:     cfg.ScheduleMisfireGrace,
+	}
+}
+
+// Run fires due schedules until ctx is done.
+func (r *ScheduleRunner) Run(ctx context.Context) {
+	log.Println("[CRON] Schedule runner started")
+	ctx = middleware.WithActor(ctx, "schedule")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches are due, e.g. after downtime
+		fired := r.fireDue(ctx)
+		if fired == scheduleBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[CRON] Schedule runner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireDue fires one batch of due schedules and returns how many were claimed.
+func (r *ScheduleRunner) fireDue(ctx context.Context) int {
+	claimed := 0
+	err := inTx(ctx, r.db, func(ctx context.Context) *common.AppError {
+		due, appErr := r.schedules.ClaimDueSchedules(ctx, scheduleBatchSize)
+		if appErr != nil {
+			return appErr
+		}
+		claimed = len(due)
+
+		now := time.Now()
+		for _, jobSchedule := range due {
+			spec, err := schedule.Parse(jobSchedule.CronSpec, jobSchedule.Timezone)
+			if err != nil {
+				// Disable the schedule rather than claim it again on every tick
+				log.Printf("[CRON] Schedule %s can't be evaluated, disabling it: %v\n", jobSchedule.ID, err)
+				if appErr := r.schedules.MaterializeSchedule(ctx, jobSchedule, nil, time.Time{}); appErr != nil {
+					return appErr
+				}
+				continue
+			}
+
+			runAts, nextRunAt := spec.Due(jobSchedule.NextRunAt, now, jobSchedule.CatchUp, r.grace)
+			if missed := len(runAts); missed > 1 || (missed == 0 && jobSchedule.CatchUp == schedule.CatchUpSkip) {
+				log.Printf("[CRON] Schedule %s missed runs, catching up with policy %q (%d runs)\n", jobSchedule.ID, jobSchedule.CatchUp, missed)
+			}
+			if appErr := r.schedules.MaterializeSchedule(ctx, jobSchedule, runAts, nextRunAt); appErr != nil {
+				return appErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to fire due schedules: %v\n", err)
+		return 0
+	}
+	return claimed
+}
@@ -0,0 +1,394 @@
+package worker
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/config"
+	"github.com/Nezent/go-queue/internal/bootstrap"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Dispatcher hands a due job over to the background workers.
+type Dispatcher interface {
+	EnqueueJob(context.Context, uuid.UUID, jobtype.Type, json.RawMessage) error
+}
+
+// JobStatusStore persists the status and attempt count of a job.
+type JobStatusStore interface {
+	UpdateJobStatus(context.Context, uuid.UUID, string, int) error
+	// QueueJob marks a job as queued and returns the payload to dispatch
+	QueueJob(context.Context, uuid.UUID, int) (json.RawMessage, error)
+	RetryJob(context.Context, uuid.UUID, int, time.Time, string) error
+	FailJob(context.Context, uuid.UUID, int, string) error
+}
+
+// Throttler books dispatch slots for jobs, returning when a job may be dispatched.
+type Throttler interface {
+	Reserve(jobType string, userID uuid.UUID, now time.Time) time.Time
+}
+
+// JobSource loads the jobs a scheduler has to recover on startup.
+type JobSource interface {
+	GetRecoverableJobs(context.Context) ([]task.JobPayload, error)
+}
+
+// Scheduler keeps jobs in a priority heap ordered by RunAt and priority and
+// dispatches each one as soon as it is due.
+type Scheduler struct {
+	mu sync.Mutex
+	// queue holds the jobs waiting for their run time, ready the due ones
+	queue JobPriorityQueue
+	ready ReadyQueue
+	items map[uuid.UUID]*JobItem
+	// wake interrupts Run's sleep when the head of the heap changes
+	wake chan struct{}
+
+	dispatcher Dispatcher
+	types      *jobtype.Registry
+	store      JobStatusStore
+	policies   retry.Policies
+	throttler  Throttler
+}
+
+func NewScheduler(dispatcher Dispatcher, types *jobtype.Registry, store JobStatusStore, policies retry.Policies, throttler Throttler, ready ReadyQueue) *Scheduler {
+	return &Scheduler{
+		queue:      JobPriorityQueue{},
+		ready:      ready,
+		items:      make(map[uuid.UUID]*JobItem),
+		wake:       make(chan struct{}, 1),
+		dispatcher: dispatcher,
+		types:      types,
+		store:      store,
+		policies:   policies,
+		throttler:  throttler,
+	}
+}
+
+// Push adds a job to the heap. A job that is already queued is updated in
+// place instead of being added twice.
+func (s *Scheduler) Push(job *JobItem) {
+	s.mu.Lock()
+	existing, ok := s.items[job.ID]
+	switch {
+	case ok && !existing.ready:
+		// Edited jobs keep their heap entry, only its position changes
+		index := existing.index
+		*existing = *job
+		existing.index = index
+		heap.Fix(&s.queue, index)
+		job = existing
+	case ok:
+		// A due job may have been moved to a later run time
+		s.ready.Remove(existing)
+		*existing = *job
+		heap.Push(&s.queue, existing)
+		job = existing
+	default:
+		heap.Push(&s.queue, job)
+	}
+	s.items[job.ID] = job
+	isHead := job.index == 0
+	s.mu.Unlock()
+
+	if isHead {
+		s.wakeUp()
+	}
+}
+
+// Remove drops a job from the heap and reports whether it was queued.
+func (s *Scheduler) Remove(jobID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.items[jobID]
+	if !ok {
+		return false
+	}
+	if job.ready {
+		s.ready.Remove(job)
+	} else {
+		heap.Remove(&s.queue, job.index)
+	}
+	delete(s.items, jobID)
+	return true
+}
+
+// Peek returns a copy of the job that is due next.
+func (s *Scheduler) Peek() (JobItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return JobItem{}, false
+	}
+	return *s.queue[0], true
+}
+
+// reset drops every queued job.
+func (s *Scheduler) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queue = JobPriorityQueue{}
+	s.ready.Clear()
+	s.items = make(map[uuid.UUID]*JobItem)
+}
+
+// Len returns the number of queued jobs, due or not.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue) + s.ready.Len()
+}
+
+// Recover loads every job that waits to run into the heap, so a restart of the
+// API process doesn't drop jobs that were waiting to run. Queued jobs are
+// dispatched again, in case the last leader stopped before handing them over.
+// The dispatcher drops the duplicate of a task that did reach the broker.
+func (s *Scheduler) Recover(ctx context.Context, source JobSource) error {
+	jobs, err := source.GetRecoverableJobs(ctx)
+	if err != nil {
+		log.Printf("[RECOVERY] Failed to load jobs from database: %v\n", err)
+		return err
+	}
+
+	for _, jobPayload := range jobs {
+		s.Push(newJobItem(jobPayload))
+	}
+
+	log.Printf("[RECOVERY] Restored %d jobs into the queue\n", len(jobs))
+	return nil
+}
+
+// Run sleeps until the head of the heap is due, then dispatches due jobs one
+// at a time in the order of the ready queue. Pushing an earlier job wakes it
+// up early.
+func (s *Scheduler) Run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		wait, ok := s.promoteDue(time.Now())
+
+		if job := s.nextReady(); job != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.processJob(ctx, job)
+			// Dispatching takes time, so look at the heap again before the next job
+			continue
+		}
+
+		var timeout <-chan time.Time
+		if !ok {
+			log.Println("Job queue is empty, waiting for jobs...")
+		} else {
+			timer.Reset(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// promoteDue moves every job due at now from the heap to the ready queue. It
+// returns how long until the next job is due, and false if the heap is empty.
+func (s *Scheduler) promoteDue(now time.Time) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.queue) > 0 && !s.queue[0].RunAt.After(now) {
+		job := heap.Pop(&s.queue).(*JobItem)
+		job.ready = true
+		s.ready.Push(job)
+	}
+	if len(s.queue) == 0 {
+		return 0, false
+	}
+	return s.queue[0].RunAt.Sub(now), true
+}
+
+// nextReady removes the job to dispatch next from the ready queue, or returns
+// nil if no job is due.
+func (s *Scheduler) nextReady() *JobItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.ready.Pop()
+	if job == nil {
+		return nil
+	}
+	job.ready = false
+	delete(s.items, job.ID)
+	return job
+}
+
+// wakeUp signals Run without blocking; one pending signal is enough.
+func (s *Scheduler) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sync applies a job change read from the database: the job is queued while
+// it waits to run or dropped from the heap otherwise.
+func (s *Scheduler) Sync(jobPayload task.JobPayload) {
+	switch jobPayload.Status {
+	case "pending", "retrying":
+		log.Printf("[SCHEDULER] Queueing job ID %s with priority %s and run_at %s\n", jobPayload.ID, jobPayload.Priority, jobPayload.RunAt)
+		s.Push(newJobItem(jobPayload))
+	default:
+		s.Remove(jobPayload.ID)
+	}
+}
+
+// processJob hands a due job over to the dispatcher. Every dispatch counts as
+// one attempt; a failed dispatch is retried according to the job's policy.
+func (s *Scheduler) processJob(ctx context.Context, nextJob *JobItem) {
+	// Throttled jobs wait in the heap for their slot, they are neither dispatched nor failed
+	if !nextJob.admitted {
+		nextJob.admitted = true
+		now := time.Now()
+		if at := s.throttler.Reserve(nextJob.JobType, nextJob.UserID, now); at.After(now) {
+			log.Printf("[PROCESS] Job ID %s is rate limited, deferring it to %s\n", nextJob.ID, at)
+			nextJob.RunAt = at
+			s.Push(nextJob)
+			return
+		}
+	}
+
+	log.Printf("[PROCESS] Processing job ID %s...\n", nextJob.ID)
+
+	// Jobs are validated on creation, but their type may have been unregistered since
+	jobType, ok := s.types.Lookup(nextJob.JobType)
+	if !ok {
+		log.Printf("[PROCESS] Job ID %s has unknown type %q, marking as failed.\n", nextJob.ID, nextJob.JobType)
+		nextJob.Status = "failed"
+		if err := s.store.FailJob(ctx, nextJob.ID, nextJob.Attempts, "unknown job type "+nextJob.JobType); err != nil {
+			log.Printf("[ERROR] Failed to dead-letter job: %v\n", err)
+		}
+		return
+	}
+
+	policy := s.policies.For(nextJob.JobType, nextJob.Retry)
+	// A recovered queued job was counted when it was queued, this dispatches the same attempt
+	if nextJob.Status != "queued" {
+		nextJob.Attempts++
+	}
+
+	// Persist the attempt before dispatching, so a worker never runs a job the database still sees as due
+	nextJob.Status = "queued"
+	payload, err := s.store.QueueJob(ctx, nextJob.ID, nextJob.Attempts)
+	if err != nil {
+		var appErr *common.AppError
+		if errors.As(err, &appErr) && appErr.StatusCode == http.StatusNotFound {
+			log.Printf("[PROCESS] Job ID %s was cancelled, skipping.\n", nextJob.ID)
+			return
+		}
+		log.Printf("[ERROR] Failed to update job status: %v\n", err)
+	} else {
+		// The job may have been edited since it was read, dispatch what the database has now
+		nextJob.Payload = payload
+	}
+
+	err = s.dispatcher.EnqueueJob(ctx, nextJob.ID, jobType, nextJob.Payload)
+	if err == nil {
+		log.Printf("[PROCESS] Job ID %s dispatched (attempt %d).\n", nextJob.ID, nextJob.Attempts)
+		return
+	}
+
+	status, runAt := nextState(policy, nextJob.Attempts, err, time.Now())
+	if status == "failed" {
+		log.Printf("[PROCESS] Job ID %s failed after %d attempts, marking as failed: %v\n", nextJob.ID, nextJob.Attempts, err)
+		nextJob.Status = status
+		if err := s.store.FailJob(ctx, nextJob.ID, nextJob.Attempts, err.Error()); err != nil {
+			log.Printf("[ERROR] Failed to dead-letter job: %v\n", err)
+		}
+		return
+	}
+
+	log.Printf("[PROCESS] Job ID %s failed, retrying at %s... (Attempt %d): %v\n", nextJob.ID, runAt, nextJob.Attempts, err)
+	nextJob.Status = status
+	nextJob.RunAt = runAt
+	nextJob.admitted = false
+	if err := s.store.RetryJob(ctx, nextJob.ID, nextJob.Attempts, nextJob.RunAt, err.Error()); err != nil {
+		log.Printf("[ERROR] Failed to schedule job retry: %v\n", err)
+	}
+	s.Push(nextJob)
+}
+
+// NewJobQueue builds a scheduler backed by the container's dispatcher, job
+// types, job handler and rate limiter, retrying jobs according
+// to policies and sharing dispatch between users as configured.
+func NewJobQueue(c *bootstrap.Container, db *pgxpool.Pool, policies retry.Policies, cfg config.QueueConfig) *Scheduler {
+	ready := NewFIFOQueue(cfg.PriorityAgingInterval)
+	if cfg.Fairness == config.FairnessDRR {
+		ready = NewFairQueue(cfg.UserWeights, cfg.PriorityAgingInterval)
+	}
+	return NewScheduler(c.TaskDispatcher, c.JobTypes, NewJobStatusStore(db, &c.JobHandler), policies, c.RateLimiter, ready)
+}
+
+// LeadJobQueue runs the scheduler for one leadership term. It has the
+// scheduler synced with job updates, rehydrates the heap from Postgres, then
+// drains the heap until ctx is done, i.e. until leadership is lost.
+func LeadJobQueue(ctx context.Context, scheduler *Scheduler, notifications *JobNotifications, c *bootstrap.Container) {
+	// Another replica may have run jobs since our last term, start from scratch
+	scheduler.reset()
+	ctx = middleware.WithActor(ctx, "scheduler")
+
+	// Listen before recovering, so no job changed in between is missed. Push
+	// keeps a single heap entry for jobs both of them deliver, and a stale
+	// entry of a job cancelled meanwhile is skipped when it is dispatched
+	if !notifications.Lead(ctx, scheduler) {
+		return
+	}
+	if err := scheduler.Recover(ctx, &c.JobHandler); err != nil {
+		return
+	}
+
+	go saveRateLimits(ctx, c)
+	scheduler.Run(ctx)
+}
+
+// rateLimitSaveInterval is how often the leader saves its token buckets.
+const rateLimitSaveInterval = time.Second
+
+// saveRateLimits saves the token buckets of the leader's rate limiter until
+// ctx is done, so every replica reports them rather than its own idle ones.
+func saveRateLimits(ctx context.Context, c *bootstrap.Container) {
+	ticker := time.NewTicker(rateLimitSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := c.AdminHandler.SaveRateLimits(ctx, c.RateLimiter.State(now), now); err != nil {
+				log.Printf("[RATELIMIT] Failed to save rate limits: %v\n", err)
+			}
+		}
+	}
+}
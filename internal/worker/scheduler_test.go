@@ -0,0 +1,337 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const testJobType = "test"
+
+// fakeDispatcher records the jobs it is handed, failing with err if set.
+type fakeDispatcher struct {
+	mu         sync.Mutex
+	err        error
+	dispatched []uuid.UUID
+	payloads   []json.RawMessage
+	// notify receives the ID of every dispatched job if not nil
+	notify chan uuid.UUID
+}
+
+func (d *fakeDispatcher) EnqueueJob(_ context.Context, jobID uuid.UUID, _ jobtype.Type, payload json.RawMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return d.err
+	}
+	d.dispatched = append(d.dispatched, jobID)
+	d.payloads = append(d.payloads, payload)
+	if d.notify != nil {
+		d.notify <- jobID
+	}
+	return nil
+}
+
+// fakeStore records the status changes the scheduler persists.
+type fakeStore struct {
+	mu        sync.Mutex
+	cancelled map[uuid.UUID]bool
+	// edited holds the payloads jobs were edited to since the scheduler read them
+	edited   map[uuid.UUID]json.RawMessage
+	updates  []string
+	retries  []time.Time
+	failures []string
+}
+
+func (s *fakeStore) UpdateJobStatus(_ context.Context, jobID uuid.UUID, status string, attempts int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelled[jobID] {
+		return common.NewNotFoundError("Job not found or cancelled")
+	}
+	s.updates = append(s.updates, fmt.Sprintf("%s:%d", status, attempts))
+	return nil
+}
+
+func (s *fakeStore) QueueJob(ctx context.Context, jobID uuid.UUID, attempts int) (json.RawMessage, error) {
+	if err := s.UpdateJobStatus(ctx, jobID, "queued", attempts); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.edited[jobID], nil
+}
+
+func (s *fakeStore) RetryJob(_ context.Context, _ uuid.UUID, _ int, runAt time.Time, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries = append(s.retries, runAt)
+	return nil
+}
+
+func (s *fakeStore) FailJob(_ context.Context, _ uuid.UUID, _ int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, reason)
+	return nil
+}
+
+// noThrottle admits every job right away.
+type noThrottle struct{}
+
+func (noThrottle) Reserve(string, uuid.UUID, time.Time) time.Time { return time.Time{} }
+
+func newTestScheduler(dispatcher *fakeDispatcher, store *fakeStore, policy retry.Policy) *Scheduler {
+	types := jobtype.NewRegistry()
+	types.Register(jobtype.Type{
+		Name:    testJobType,
+		Decode:  jobtype.JSON[struct{}](),
+		Handler: func(context.Context, *asynq.Task) error { return nil },
+	})
+	return NewScheduler(dispatcher, types, store, retry.Policies{Default: policy}, noThrottle{}, NewFIFOQueue(0))
+}
+
+func newTestJob(runAt time.Time) *JobItem {
+	return &JobItem{ID: uuid.New(), RunAt: runAt, JobType: testJobType, Status: "pending"}
+}
+
+func TestSchedulerPushUpdatesQueuedJob(t *testing.T) {
+	s := newTestScheduler(&fakeDispatcher{}, &fakeStore{}, retry.DefaultPolicy)
+	now := time.Now()
+
+	job := newTestJob(now.Add(time.Hour))
+	s.Push(job)
+	s.Push(newTestJob(now.Add(2 * time.Hour)))
+
+	edited := *job
+	edited.RunAt = now.Add(3 * time.Hour)
+	s.Push(&edited)
+
+	if got := s.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+	if head, _ := s.Peek(); head.ID == job.ID {
+		t.Error("the edited job is still the head of the heap")
+	}
+}
+
+func TestSchedulerPushMovesDueJobBack(t *testing.T) {
+	s := newTestScheduler(&fakeDispatcher{}, &fakeStore{}, retry.DefaultPolicy)
+	now := time.Now()
+
+	job := newTestJob(now)
+	s.Push(job)
+	s.promoteDue(now)
+	if _, ok := s.Peek(); ok {
+		t.Fatal("the due job is still in the heap")
+	}
+
+	later := *job
+	later.RunAt = now.Add(time.Hour)
+	s.Push(&later)
+
+	if got := s.Len(); got != 1 {
+		t.Fatalf("Len() = %d, want 1", got)
+	}
+	if job := s.nextReady(); job != nil {
+		t.Errorf("job %s is still ready", job.ID)
+	}
+	if head, ok := s.Peek(); !ok || !head.RunAt.Equal(later.RunAt) {
+		t.Errorf("Peek() = %v, %v, want the job at %s", head.RunAt, ok, later.RunAt)
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	s := newTestScheduler(&fakeDispatcher{}, &fakeStore{}, retry.DefaultPolicy)
+	now := time.Now()
+
+	waiting := newTestJob(now.Add(time.Hour))
+	due := newTestJob(now)
+	s.Push(waiting)
+	s.Push(due)
+	s.promoteDue(now)
+
+	if !s.Remove(waiting.ID) {
+		t.Error("Remove() of a waiting job = false, want true")
+	}
+	if !s.Remove(due.ID) {
+		t.Error("Remove() of a due job = false, want true")
+	}
+	if s.Remove(due.ID) {
+		t.Error("Remove() of a removed job = true, want false")
+	}
+	if got := s.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+	if job := s.nextReady(); job != nil {
+		t.Errorf("removed job %s is still ready", job.ID)
+	}
+}
+
+func TestSchedulerRunWakesUpForEarlierJob(t *testing.T) {
+	dispatcher := &fakeDispatcher{notify: make(chan uuid.UUID, 1)}
+	s := newTestScheduler(dispatcher, &fakeStore{}, retry.DefaultPolicy)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	s.Push(newTestJob(time.Now().Add(time.Hour)))
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	// Run is asleep until the job an hour away, the earlier one must wake it up
+	early := newTestJob(time.Now().Add(50 * time.Millisecond))
+	s.Push(early)
+
+	select {
+	case jobID := <-dispatcher.notify:
+		if jobID != early.ID {
+			t.Errorf("dispatched job %s, want %s", jobID, early.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the earlier job was not dispatched")
+	}
+}
+
+func TestSchedulerProcessJob(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 2, BaseDelay: retry.Duration(time.Minute), Multiplier: 1}
+
+	t.Run("dispatched", func(t *testing.T) {
+		dispatcher, store := &fakeDispatcher{}, &fakeStore{}
+		s := newTestScheduler(dispatcher, store, policy)
+
+		s.processJob(context.Background(), newTestJob(time.Now()))
+
+		if len(dispatcher.dispatched) != 1 {
+			t.Errorf("dispatched %d jobs, want 1", len(dispatcher.dispatched))
+		}
+		if len(store.updates) != 1 || store.updates[0] != "queued:1" {
+			t.Errorf("status updates = %v, want [queued:1]", store.updates)
+		}
+	})
+
+	t.Run("retried then failed", func(t *testing.T) {
+		dispatcher, store := &fakeDispatcher{err: errors.New("broker unavailable")}, &fakeStore{}
+		s := newTestScheduler(dispatcher, store, policy)
+		job := newTestJob(time.Now())
+
+		before := time.Now()
+		s.processJob(context.Background(), job)
+		if len(store.retries) != 1 || store.retries[0].Before(before.Add(time.Minute)) {
+			t.Fatalf("retries = %v, want one a minute later", store.retries)
+		}
+		head, ok := s.Peek()
+		if !ok || head.ID != job.ID || head.Status != "retrying" || head.Attempts != 1 {
+			t.Fatalf("Peek() = %+v, %v, want the job retrying after 1 attempt", head, ok)
+		}
+
+		s.promoteDue(head.RunAt)
+		s.processJob(context.Background(), s.nextReady())
+		if len(store.failures) != 1 {
+			t.Fatalf("failures = %v, want one once the attempts are exhausted", store.failures)
+		}
+		if got := s.Len(); got != 0 {
+			t.Errorf("Len() = %d, want the failed job dropped", got)
+		}
+	})
+
+	t.Run("skip retry", func(t *testing.T) {
+		dispatcher, store := &fakeDispatcher{err: fmt.Errorf("%w: bad payload", asynq.SkipRetry)}, &fakeStore{}
+		s := newTestScheduler(dispatcher, store, policy)
+
+		s.processJob(context.Background(), newTestJob(time.Now()))
+
+		if len(store.retries) != 0 || len(store.failures) != 1 {
+			t.Errorf("retries = %v, failures = %v, want the job failed at once", store.retries, store.failures)
+		}
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		dispatcher, store := &fakeDispatcher{}, &fakeStore{}
+		s := newTestScheduler(dispatcher, store, policy)
+		job := newTestJob(time.Now())
+		job.JobType = "unregistered"
+
+		s.processJob(context.Background(), job)
+
+		if len(dispatcher.dispatched) != 0 || len(store.failures) != 1 {
+			t.Errorf("dispatched = %v, failures = %v, want the job failed", dispatcher.dispatched, store.failures)
+		}
+	})
+
+	t.Run("edited after it was read", func(t *testing.T) {
+		job := newTestJob(time.Now())
+		job.Payload = json.RawMessage(`{"to":"old@example.com"}`)
+		edited := json.RawMessage(`{"to":"new@example.com"}`)
+		dispatcher, store := &fakeDispatcher{}, &fakeStore{edited: map[uuid.UUID]json.RawMessage{job.ID: edited}}
+		s := newTestScheduler(dispatcher, store, policy)
+
+		s.processJob(context.Background(), job)
+
+		if len(dispatcher.payloads) != 1 || string(dispatcher.payloads[0]) != string(edited) {
+			t.Errorf("dispatched payloads = %s, want the edited payload %s", dispatcher.payloads, edited)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		job := newTestJob(time.Now())
+		dispatcher, store := &fakeDispatcher{}, &fakeStore{cancelled: map[uuid.UUID]bool{job.ID: true}}
+		s := newTestScheduler(dispatcher, store, policy)
+
+		s.processJob(context.Background(), job)
+
+		if len(dispatcher.dispatched) != 0 {
+			t.Errorf("dispatched = %v, want the cancelled job skipped", dispatcher.dispatched)
+		}
+	})
+}
+
+// fakeSource serves the jobs a scheduler recovers.
+type fakeSource []task.JobPayload
+
+func (s fakeSource) GetRecoverableJobs(context.Context) ([]task.JobPayload, error) {
+	return s, nil
+}
+
+func TestSchedulerRecoverRedispatchesQueuedJob(t *testing.T) {
+	dispatcher, store := &fakeDispatcher{}, &fakeStore{}
+	s := newTestScheduler(dispatcher, store, retry.DefaultPolicy)
+
+	// The last leader stopped between queueing the job and handing it over
+	now := time.Now()
+	queued := task.JobPayload{ID: uuid.New(), JobType: testJobType, Status: "queued", Attempts: 1, RunAt: now}
+	if err := s.Recover(context.Background(), fakeSource{queued}); err != nil {
+		t.Fatalf("Recover() = %v", err)
+	}
+
+	s.promoteDue(now)
+	job := s.nextReady()
+	if job == nil || job.ID != queued.ID {
+		t.Fatalf("nextReady() = %v, want the queued job", job)
+	}
+	s.processJob(context.Background(), job)
+
+	if len(dispatcher.dispatched) != 1 || dispatcher.dispatched[0] != queued.ID {
+		t.Errorf("dispatched = %v, want the queued job", dispatcher.dispatched)
+	}
+	if len(store.updates) != 1 || store.updates[0] != "queued:1" {
+		t.Errorf("status updates = %v, want [queued:1], the attempt counted once", store.updates)
+	}
+}
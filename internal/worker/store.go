@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Nezent/go-queue/common"
+	"github.com/Nezent/go-queue/internal/handler"
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// jobStatusStore persists scheduler state changes through the job handler,
+// wrapping every write in its own transaction.
+type jobStatusStore struct {
+	db   *pgxpool.Pool
+	jobs *handler.JobHandler
+}
+
+func NewJobStatusStore(db *pgxpool.Pool, jobs *handler.JobHandler) JobStatusStore {
+	return jobStatusStore{db: db, jobs: jobs}
+}
+
+// NewJobResultStore persists job results and progress through the job handler like NewJobStatusStore.
+func NewJobResultStore(db *pgxpool.Pool, jobs *handler.JobHandler) JobResultStore {
+	return jobStatusStore{db: db, jobs: jobs}
+}
+
+func (s jobStatusStore) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string, attempts int) error {
+	return s.inTx(ctx, func(ctx context.Context) *common.AppError {
+		return s.jobs.UpdateJobStatus(ctx, jobID, status, attempts)
+	})
+}
+
+func (s jobStatusStore) QueueJob(ctx context.Context, jobID uuid.UUID, attempts int) (json.RawMessage, error) {
+	var payload json.RawMessage
+	err := s.inTx(ctx, func(ctx context.Context) *common.AppError {
+		var appErr *common.AppError
+		payload, appErr = s.jobs.QueueJob(ctx, jobID, attempts)
+		return appErr
+	})
+	return payload, err
+}
+
+func (s jobStatusStore) RetryJob(ctx context.Context, jobID uuid.UUID, attempts int, runAt time.Time, lastError string) error {
+	return s.inTx(ctx, func(ctx context.Context) *common.AppError {
+		return s.jobs.RetryJob(ctx, jobID, attempts, runAt, lastError)
+	})
+}
+
+func (s jobStatusStore) FailJob(ctx context.Context, jobID uuid.UUID, attempts int, lastError string) error {
+	return s.inTx(ctx, func(ctx context.Context) *common.AppError {
+		return s.jobs.FailJob(ctx, jobID, attempts, lastError)
+	})
+}
+
+func (s jobStatusStore) SaveJobResult(ctx context.Context, jobID uuid.UUID, result json.RawMessage) error {
+	return s.inTx(ctx, func(ctx context.Context) *common.AppError {
+		return s.jobs.SaveJobResult(ctx, jobID, result)
+	})
+}
+
+func (s jobStatusStore) SaveJobProgress(ctx context.Context, jobID uuid.UUID, percent int, message string) error {
+	return s.jobs.SaveJobProgress(ctx, jobID, percent, message)
+}
+
+func (s jobStatusStore) inTx(ctx context.Context, fn func(context.Context) *common.AppError) error {
+	return inTx(ctx, s.db, fn)
+}
+
+// inTx runs fn with a fresh transaction in its context and commits it if fn succeeds.
+func inTx(ctx context.Context, db *pgxpool.Pool, fn func(context.Context) *common.AppError) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		log.Println("failed to start transaction:", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := middleware.SetTxSession(ctx, tx); err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, middleware.TxKey, tx)
+	if appErr := fn(ctx); appErr != nil {
+		return appErr
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,88 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type jobKey struct{}
+
+// WithJob returns a copy of ctx carrying the job a handler runs for.
+func WithJob(ctx context.Context, job JobPayload) context.Context {
+	return context.WithValue(ctx, jobKey{}, job)
+}
+
+// JobFromContext returns the job a handler runs for, if it runs for one.
+func JobFromContext(ctx context.Context) (JobPayload, bool) {
+	job, ok := ctx.Value(jobKey{}).(JobPayload)
+	return job, ok
+}
+
+type resultKey struct{}
+
+// result holds what a handler recorded with SetResult.
+type result struct {
+	mu   sync.Mutex
+	data json.RawMessage
+}
+
+// WithResult returns a copy of ctx handlers can record a result in, and a
+// function returning the result recorded last, or nil.
+func WithResult(ctx context.Context) (context.Context, func() json.RawMessage) {
+	r := &result{}
+	return context.WithValue(ctx, resultKey{}, r), func() json.RawMessage {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.data
+	}
+}
+
+// SetResult records v as the result of the job running in ctx, replacing any
+// earlier result. Outside of a job it does nothing.
+//
+// With the Redis broker handlers may write their result to the task's
+// ResultWriter instead; tasks run by the Postgres broker have none.
+func SetResult(ctx context.Context, v any) error {
+	r, ok := ctx.Value(resultKey{}).(*result)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = data
+	return nil
+}
+
+// MaxProgressMessage caps the message of a progress report, in characters.
+const MaxProgressMessage = 255
+
+type progressKey struct{}
+
+// ProgressReporter receives the progress reports of a running job.
+type ProgressReporter interface {
+	Report(percent int, message string)
+}
+
+// WithProgress returns a copy of ctx whose progress reports go to reporter.
+func WithProgress(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, reporter)
+}
+
+// ReportProgress reports that the job running in ctx is percent done, along
+// with a message for the clients watching it. Outside of a job it does nothing.
+func ReportProgress(ctx context.Context, percent int, message string) {
+	reporter, ok := ctx.Value(progressKey{}).(ProgressReporter)
+	if !ok {
+		return
+	}
+	if runes := []rune(message); len(runes) > MaxProgressMessage {
+		message = string(runes[:MaxProgressMessage])
+	}
+	reporter.Report(min(max(percent, 0), 100), message)
+}
@@ -1,6 +1,17 @@
 package task
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/google/uuid"
+)
 
 type SendVerificationEmailPayload struct {
 	Email string
@@ -13,17 +24,97 @@ type EmailPayload struct {
 	Body      string `json:"body"`
 }
 
+func (p EmailPayload) Validate() error {
+	if _, err := mail.ParseAddress(p.Recipient); err != nil {
+		return errors.New("recipient must be a valid email address")
+	}
+	if p.Subject == "" {
+		return errors.New("subject is required")
+	}
+	return nil
+}
+
+// HTTPRequestPayload is the request an http:request job sends.
+type HTTPRequestPayload struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Timeout bounds the request, DefaultHTTPTimeout if unset
+	Timeout retry.Duration `json:"timeout,omitempty"`
+}
+
+// DefaultHTTPTimeout and MaxHTTPTimeout bound the requests of http:request jobs.
+const (
+	DefaultHTTPTimeout = 30 * time.Second
+	MaxHTTPTimeout     = 5 * time.Minute
+)
+
+func (p *HTTPRequestPayload) Validate() error {
+	if p.Method == "" {
+		p.Method = http.MethodPost
+	}
+	p.Method = strings.ToUpper(p.Method)
+	switch p.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return errors.New("unsupported method " + p.Method)
+	}
+
+	target, err := url.Parse(p.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if p.Timeout < 0 || time.Duration(p.Timeout) > MaxHTTPTimeout {
+		return errors.New("timeout must be between 0 and 5m")
+	}
+	return nil
+}
+
+// HTTPResponseResult is the result of an http:request job: the response it
+// got, with the body cut to MaxHTTPResultBody bytes.
+type HTTPResponseResult struct {
+	StatusCode    int         `json:"status_code"`
+	Headers       http.Header `json:"headers"`
+	Body          string      `json:"body"`
+	BodyTruncated bool        `json:"body_truncated"`
+}
+
+// MaxHTTPResultBody caps the response body kept as a job result.
+const MaxHTTPResultBody = 64 << 10
+
 type JobPayload struct {
-	Priority string       `json:"priority"`
-	RunAt    time.Time    `json:"run_at"`
-	Attempts int          `json:"attempts"`
-	JobType  string       `json:"job_type"`
-	Status   string       `json:"status"`
-	Payload  EmailPayload `json:"payload"`
+	ID       uuid.UUID       `json:"id"`
+	Priority string          `json:"priority"`
+	RunAt    time.Time       `json:"run_at"`
+	Attempts int             `json:"attempts"`
+	JobType  string          `json:"job_type"`
+	Status   string          `json:"status"`
+	Payload  json.RawMessage `json:"payload"`
+	Retry    *retry.Override `json:"retry,omitempty"`
+	BatchID  *uuid.UUID      `json:"batch_id,omitempty"`
+	UserID   uuid.UUID       `json:"user_id"`
 }
 
+// WebSocketPayload is broadcast when a job changes status, as a "status"
+// event, or reports progress while it runs, as a "progress" event.
 type WebSocketPayload struct {
+	Event   string `json:"event"`
 	JobID   string `json:"job_id"`
 	JobType string `json:"job_type"`
 	Status  string `json:"status"`
+	// Progress and Message are only sent with progress events
+	Progress *int   `json:"progress,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// BatchProgressPayload is broadcast whenever a job of a batch changes status.
+type BatchProgressPayload struct {
+	Event     string `json:"event"`
+	BatchID   string `json:"batch_id"`
+	Total     int    `json:"total"`
+	Pending   int    `json:"pending"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+	Done      bool   `json:"done"`
 }
@@ -1,8 +1,13 @@
 package task
 
+// JobTypeEmail is the job type users create to send an EmailPayload.
+const JobTypeEmail = "email"
+
 const (
 	TaskSendVerificationEmail = "email:send_verification"
 	TaskSendJobEmail          = "email:send_email"
+	// TaskHTTPRequest is both the job type and the task of outbound webhooks
+	TaskHTTPRequest = "http:request"
 	// Add other tasks here, e.g.:
 	// TaskCleanupSessions = "system:cleanup_sessions"
 )
@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Nezent/go-queue/internal/middleware"
+	"github.com/Nezent/go-queue/internal/worker/jobtype"
+	"github.com/Nezent/go-queue/internal/worker/retry"
+	"github.com/Nezent/go-queue/internal/worker/task"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// JobFetcher loads a job by its ID.
+type JobFetcher interface {
+	GetJobPayload(context.Context, uuid.UUID) (*task.JobPayload, error)
+}
+
+// JobResultStore persists what the handler of a job reports: its progress and result.
+type JobResultStore interface {
+	JobProgressStore
+	SaveJobResult(context.Context, uuid.UUID, json.RawMessage) error
+}
+
+// TaskInspector reads what a handler wrote to the ResultWriter of its task.
+type TaskInspector interface {
+	GetTaskInfo(queue string, id string) (*asynq.TaskInfo, error)
+}
+
+// JobTracker records the outcome of job tasks run by asynq workers in the
+// jobs table. asynq itself never retries a job task: a failed attempt is
+// rescheduled as "retrying" and dispatched again by the scheduler, so the
+// attempts column stays the only attempts counter.
+type JobTracker struct {
+	jobs     JobFetcher
+	types    *jobtype.Registry
+	store    JobStatusStore
+	results  JobResultStore
+	tasks    TaskInspector
+	policies retry.Policies
+	// progressInterval throttles the progress reports saved per job
+	progressInterval time.Duration
+}
+
+func NewJobTracker(jobs JobFetcher, types *jobtype.Registry, store JobStatusStore, results JobResultStore, tasks TaskInspector, policies retry.Policies, progressInterval time.Duration) *JobTracker {
+	return &JobTracker{
+		jobs:             jobs,
+		types:            types,
+		store:            store,
+		results:          results,
+		tasks:            tasks,
+		policies:         policies,
+		progressInterval: progressInterval,
+	}
+}
+
+// Middleware wraps the handlers of job tasks; internal tasks pass through untouched.
+func (jt *JobTracker) Middleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		if jobType, ok := jt.types.LookupTask(t.Type()); !ok || jobType.Internal {
+			return next.ProcessTask(ctx, t)
+		}
+
+		taskID, _ := asynq.GetTaskID(ctx)
+		jobID, err := uuid.Parse(taskID)
+		if err != nil {
+			log.Printf("[TRACKER] Task %s is not bound to a job, running it untracked\n", taskID)
+			return next.ProcessTask(ctx, t)
+		}
+		ctx = middleware.WithActor(ctx, "worker")
+
+		job, err := jt.jobs.GetJobPayload(ctx, jobID)
+		if err != nil {
+			// Without the row there is nothing to record. Retries belong to the
+			// scheduler, a task retried or archived by asynq would block
+			// re-enqueueing the job under the same task ID
+			log.Printf("[TRACKER] Failed to load job ID %s, dropping its task: %v\n", jobID, err)
+			return fmt.Errorf("%w: failed to load job %s: %v", asynq.RevokeTask, jobID, err)
+		}
+		if job.Status == "cancelled" {
+			log.Printf("[TRACKER] Job ID %s was cancelled, dropping its task.\n", jobID)
+			return fmt.Errorf("%w: job %s was cancelled", asynq.RevokeTask, jobID)
+		}
+
+		if err := jt.store.UpdateJobStatus(ctx, jobID, "processing", job.Attempts); err != nil {
+			log.Printf("[ERROR] Failed to update job status: %v\n", err)
+		}
+
+		progress := newProgressReporter(ctx, jt.results, jobID, jt.progressInterval)
+		jobCtx, result := task.WithResult(task.WithProgress(task.WithJob(ctx, *job), progress))
+		handlerErr := next.ProcessTask(jobCtx, t)
+		progress.Flush()
+
+		// Failed attempts keep their result too, e.g. the error response of a webhook
+		data := result()
+		if data == nil {
+			data = jt.writtenResult(ctx, taskID)
+		}
+		if data != nil {
+			if err := jt.results.SaveJobResult(context.WithoutCancel(ctx), jobID, data); err != nil {
+				log.Printf("[ERROR] Failed to save result of job %s: %v\n", jobID, err)
+			}
+		}
+
+		policy := jt.policies.For(job.JobType, job.Retry)
+		status, runAt := nextState(policy, job.Attempts, handlerErr, time.Now())
+		jt.record(context.WithoutCancel(ctx), jobID, status, job.Attempts, runAt, handlerErr)
+
+		if handlerErr != nil {
+			log.Printf("[TRACKER] Job ID %s attempt %d failed, now %s: %v\n", jobID, job.Attempts, status, handlerErr)
+			// Neither retry nor archive the task in asynq, the job was rescheduled above
+			return fmt.Errorf("%w: %v", asynq.RevokeTask, handlerErr)
+		}
+		log.Printf("[TRACKER] Job ID %s completed.\n", jobID)
+		return nil
+	})
+}
+
+// writtenResult returns what the handler wrote to the task's ResultWriter as
+// JSON, or nil. Results that aren't JSON are stored as a string.
+func (jt *JobTracker) writtenResult(ctx context.Context, taskID string) json.RawMessage {
+	queue, _ := asynq.GetQueueName(ctx)
+	info, err := jt.tasks.GetTaskInfo(queue, taskID)
+	if err != nil || len(info.Result) == 0 {
+		return nil
+	}
+	if json.Valid(info.Result) {
+		return info.Result
+	}
+	data, err := json.Marshal(string(info.Result))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (jt *JobTracker) record(ctx context.Context, jobID uuid.UUID, status string, attempts int, runAt time.Time, handlerErr error) {
+	var err error
+	switch status {
+	case "retrying":
+		err = jt.store.RetryJob(ctx, jobID, attempts, runAt, handlerErr.Error())
+	case "failed":
+		err = jt.store.FailJob(ctx, jobID, attempts, handlerErr.Error())
+	default:
+		err = jt.store.UpdateJobStatus(ctx, jobID, status, attempts)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to record outcome of job %s: %v\n", jobID, err)
+	}
+}
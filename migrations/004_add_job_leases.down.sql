@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_jobs_claimable;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS locked_until,
+DROP COLUMN IF EXISTS locked_by;
@@ -0,0 +1,7 @@
+-- Lease columns used by the Postgres broker to claim jobs with SKIP LOCKED
+ALTER TABLE jobs
+ADD COLUMN locked_by TEXT,
+ADD COLUMN locked_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_claimable ON jobs (run_at)
+WHERE status IN ('pending', 'processing');
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jobs_claimable;
+CREATE INDEX idx_jobs_claimable ON jobs (run_at)
+WHERE status IN ('pending', 'processing');
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS retry_policy;
@@ -0,0 +1,8 @@
+-- Per-job retry policy override, merged over the policy of the job type
+ALTER TABLE jobs
+ADD COLUMN retry_policy JSONB;
+
+-- Jobs waiting for their next attempt are now "retrying", "processing" means running
+DROP INDEX IF EXISTS idx_jobs_claimable;
+CREATE INDEX idx_jobs_claimable ON jobs (run_at)
+WHERE status IN ('pending', 'retrying', 'processing');
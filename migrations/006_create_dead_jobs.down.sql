@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS dead_jobs;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS attempt_history;
@@ -0,0 +1,17 @@
+-- Every failed attempt of a job, appended as {attempt, error, failed_at}
+ALTER TABLE jobs
+ADD COLUMN attempt_history JSONB NOT NULL DEFAULT '[]';
+
+-- Dead-letter queue: jobs that ran out of attempts, with why they failed
+CREATE TABLE dead_jobs (
+    job_id UUID PRIMARY KEY REFERENCES jobs(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id),
+    type TEXT NOT NULL,
+    payload JSONB,
+    attempts INT NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    attempt_history JSONB NOT NULL DEFAULT '[]',
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_dead_jobs_user_failed_at ON dead_jobs (user_id, failed_at DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jobs_schedule_run_at;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS job_schedules;
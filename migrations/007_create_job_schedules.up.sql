@@ -0,0 +1,24 @@
+-- Recurring jobs: each occurrence of a schedule is materialised into jobs
+CREATE TABLE job_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cron_spec TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'Asia/Dhaka',
+    job_type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    priority TEXT NOT NULL DEFAULT 'medium',
+    catch_up TEXT NOT NULL DEFAULT 'skip' CHECK (catch_up IN ('skip', 'once', 'all')),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_job_schedules_due ON job_schedules (next_run_at) WHERE enabled;
+
+-- An occurrence is materialised at most once, even if two replicas fire it
+ALTER TABLE jobs
+ADD COLUMN schedule_id UUID REFERENCES job_schedules(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_jobs_schedule_run_at ON jobs (schedule_id, run_at);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS job_dependencies_release ON jobs;
+
+DROP FUNCTION IF EXISTS release_dependent_jobs();
+
+DROP FUNCTION IF EXISTS resolve_blocked_job(UUID);
+
+DROP TABLE IF EXISTS job_dependencies;
@@ -0,0 +1,51 @@
+-- Workflow edges: job_id only runs once every job it depends on completed.
+-- on_failure decides what happens to it when one of them doesn't.
+CREATE TABLE job_dependencies (
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    depends_on UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    on_failure TEXT NOT NULL DEFAULT 'fail' CHECK (on_failure IN ('fail', 'skip')),
+    PRIMARY KEY (job_id, depends_on)
+);
+
+CREATE INDEX idx_job_dependencies_depends_on ON job_dependencies (depends_on);
+
+-- Moves a blocked job on once its parents allow it: pending when all of them
+-- completed, failed or skipped as soon as one of them didn't
+CREATE OR REPLACE FUNCTION resolve_blocked_job(child UUID)
+RETURNS void AS $$
+DECLARE
+    outcome TEXT;
+BEGIN
+    SELECT CASE
+        WHEN bool_or(p.status IN ('failed', 'cancelled', 'skipped') AND d.on_failure = 'fail') THEN 'failed'
+        WHEN bool_or(p.status IN ('failed', 'cancelled', 'skipped')) THEN 'skipped'
+        WHEN bool_and(p.status = 'completed') THEN 'pending'
+    END INTO outcome
+    FROM job_dependencies d
+    JOIN jobs p ON p.id = d.depends_on
+    WHERE d.job_id = child;
+
+    IF outcome IS NOT NULL THEN
+        UPDATE jobs SET status = outcome, updated_at = now()
+        WHERE id = child AND status = 'blocked';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Resolving a child updates its row, so outcomes cascade down the graph
+CREATE OR REPLACE FUNCTION release_dependent_jobs()
+RETURNS trigger AS $$
+BEGIN
+    PERFORM resolve_blocked_job(d.job_id)
+    FROM job_dependencies d
+    WHERE d.depends_on = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER job_dependencies_release
+AFTER UPDATE OF status ON jobs
+FOR EACH ROW
+WHEN (OLD.status IS DISTINCT FROM NEW.status
+    AND NEW.status IN ('completed', 'failed', 'cancelled', 'skipped'))
+EXECUTE FUNCTION release_dependent_jobs();
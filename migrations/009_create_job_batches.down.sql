@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS job_batches_complete ON jobs;
+
+DROP FUNCTION IF EXISTS complete_job_batch();
+
+DROP INDEX IF EXISTS idx_jobs_batch_id;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS batch_id;
+
+DROP TABLE IF EXISTS job_batches;
@@ -0,0 +1,47 @@
+-- Batches group jobs created together; once every member is done the
+-- on_complete job is released
+CREATE TABLE job_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    total INT NOT NULL,
+    on_complete_job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE jobs
+ADD COLUMN batch_id UUID REFERENCES job_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_jobs_batch_id ON jobs (batch_id) WHERE batch_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION complete_job_batch()
+RETURNS trigger AS $$
+DECLARE
+    batch job_batches%ROWTYPE;
+    finished INT;
+BEGIN
+    -- Serialises members finishing concurrently, so exactly one of them sees the batch done
+    SELECT * INTO batch FROM job_batches WHERE id = NEW.batch_id FOR UPDATE;
+    IF batch.completed_at IS NOT NULL THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT count(*) INTO finished FROM jobs
+    WHERE batch_id = NEW.batch_id AND status IN ('completed', 'failed', 'cancelled', 'skipped');
+
+    IF finished >= batch.total THEN
+        UPDATE job_batches SET completed_at = now() WHERE id = batch.id;
+        UPDATE jobs SET status = 'pending', updated_at = now()
+        WHERE id = batch.on_complete_job_id AND status = 'blocked';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER job_batches_complete
+AFTER UPDATE OF status ON jobs
+FOR EACH ROW
+WHEN (NEW.batch_id IS NOT NULL
+    AND OLD.status IS DISTINCT FROM NEW.status
+    AND NEW.status IN ('completed', 'failed', 'cancelled', 'skipped'))
+EXECUTE FUNCTION complete_job_batch();
@@ -0,0 +1,11 @@
+ALTER TABLE jobs
+DROP CONSTRAINT IF EXISTS jobs_unique_key_window;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS unique_window,
+DROP COLUMN IF EXISTS unique_key;
+
+DROP INDEX IF EXISTS idx_jobs_user_idempotency_key;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS idempotency_key;
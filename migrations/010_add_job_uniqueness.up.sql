@@ -0,0 +1,22 @@
+-- Lets the uniqueness window below be enforced by an exclusion constraint
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Idempotency-Key of the request that created the job; a replay returns the same job
+ALTER TABLE jobs
+ADD COLUMN idempotency_key TEXT;
+
+CREATE UNIQUE INDEX idx_jobs_user_idempotency_key ON jobs (user_id, idempotency_key)
+WHERE idempotency_key IS NOT NULL;
+
+-- At most one job per user and unique_key whose unique_window overlaps;
+-- an unbounded window keeps the key taken for good
+ALTER TABLE jobs
+ADD COLUMN unique_key TEXT,
+ADD COLUMN unique_window TSTZRANGE;
+
+ALTER TABLE jobs
+ADD CONSTRAINT jobs_unique_key_window EXCLUDE USING gist (
+    user_id WITH =,
+    unique_key WITH =,
+    unique_window WITH &&
+) WHERE (unique_key IS NOT NULL);
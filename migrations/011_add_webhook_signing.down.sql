@@ -0,0 +1,5 @@
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS result;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS webhook_secret;
@@ -0,0 +1,10 @@
+-- Generates the webhook secrets below
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+-- Key the user's http:request jobs are signed with
+ALTER TABLE users
+ADD COLUMN webhook_secret TEXT NOT NULL DEFAULT encode(gen_random_bytes(32), 'hex');
+
+-- What a job produced, e.g. the response to an http:request job
+ALTER TABLE jobs
+ADD COLUMN result JSONB;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS job_timing ON jobs;
+DROP FUNCTION IF EXISTS stamp_job_timing();
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS finished_at,
+DROP COLUMN IF EXISTS started_at,
+DROP COLUMN IF EXISTS last_error;
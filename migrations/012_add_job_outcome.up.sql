@@ -0,0 +1,28 @@
+-- Error of the latest failed attempt and timing of the latest attempt
+ALTER TABLE jobs
+ADD COLUMN last_error TEXT,
+ADD COLUMN started_at TIMESTAMPTZ,
+ADD COLUMN finished_at TIMESTAMPTZ;
+
+UPDATE jobs SET last_error = attempt_history -> -1 ->> 'error'
+WHERE jsonb_array_length(attempt_history) > 0;
+
+-- Stamps the attempt timing on every status change, whichever code path made it:
+-- a new attempt starts when the job is processed, a terminal status finishes it
+CREATE OR REPLACE FUNCTION stamp_job_timing()
+RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'processing' AND (OLD.status <> 'processing' OR NEW.attempts <> OLD.attempts) THEN
+        NEW.started_at := now();
+        NEW.finished_at := NULL;
+    ELSIF NEW.status <> OLD.status AND NEW.status IN ('completed', 'failed', 'cancelled', 'skipped') THEN
+        NEW.finished_at := now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER job_timing
+BEFORE UPDATE OF status, attempts ON jobs
+FOR EACH ROW
+EXECUTE FUNCTION stamp_job_timing();
@@ -0,0 +1,24 @@
+CREATE OR REPLACE FUNCTION notify_job_update()
+RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('job_updates', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION stamp_job_timing()
+RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'processing' AND (OLD.status <> 'processing' OR NEW.attempts <> OLD.attempts) THEN
+        NEW.started_at := now();
+        NEW.finished_at := NULL;
+    ELSIF NEW.status <> OLD.status AND NEW.status IN ('completed', 'failed', 'cancelled', 'skipped') THEN
+        NEW.finished_at := now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS progress_message,
+DROP COLUMN IF EXISTS progress;
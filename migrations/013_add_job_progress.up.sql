@@ -0,0 +1,44 @@
+-- Latest progress the handler of a running job reported
+ALTER TABLE jobs
+ADD COLUMN progress SMALLINT NOT NULL DEFAULT 0,
+ADD COLUMN progress_message TEXT;
+
+-- Every attempt starts over at 0%
+CREATE OR REPLACE FUNCTION stamp_job_timing()
+RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'processing' AND (OLD.status <> 'processing' OR NEW.attempts <> OLD.attempts) THEN
+        NEW.started_at := now();
+        NEW.finished_at := NULL;
+        NEW.progress := 0;
+        NEW.progress_message := NULL;
+    ELSIF NEW.status <> OLD.status AND NEW.status IN ('completed', 'failed', 'cancelled', 'skipped') THEN
+        NEW.finished_at := now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Progress reports go to their own channel: the scheduler has nothing to sync,
+-- the listener only forwards them to WebSocket clients
+CREATE OR REPLACE FUNCTION notify_job_update()
+RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND (NEW.progress, NEW.progress_message) IS DISTINCT FROM (OLD.progress, OLD.progress_message)
+        AND to_jsonb(NEW) - 'progress' - 'progress_message' = to_jsonb(OLD) - 'progress' - 'progress_message' THEN
+        PERFORM pg_notify('job_progress', json_build_object(
+            'event', 'progress',
+            'job_id', NEW.id,
+            'job_type', NEW.type,
+            'status', NEW.status,
+            'progress', NEW.progress,
+            'message', COALESCE(NEW.progress_message, '')
+        )::text);
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_notify('job_updates', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
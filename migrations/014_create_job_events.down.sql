@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS job_events_record ON jobs;
+DROP FUNCTION IF EXISTS record_job_event();
+DROP TABLE IF EXISTS job_events;
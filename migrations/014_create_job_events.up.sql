@@ -0,0 +1,50 @@
+-- Append-only timeline of every job's status transitions
+CREATE TABLE job_events (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempt INT NOT NULL,
+    actor TEXT NOT NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_job_events_job_id ON job_events (job_id, id);
+
+-- The application names who acts through the transaction local app.actor setting
+CREATE OR REPLACE FUNCTION record_job_event()
+RETURNS trigger AS $$
+DECLARE
+    kind TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        kind := 'created';
+    ELSIF NEW.status = OLD.status AND NEW.attempts = OLD.attempts THEN
+        RETURN NEW;
+    ELSIF NEW.status = 'processing' THEN
+        kind := 'dispatched';
+    ELSE
+        kind := NEW.status;
+    END IF;
+
+    INSERT INTO job_events (job_id, event, status, attempt, actor, error)
+    VALUES (
+        NEW.id,
+        kind,
+        NEW.status,
+        NEW.attempts,
+        COALESCE(NULLIF(current_setting('app.actor', true), ''), 'system'),
+        CASE WHEN TG_OP = 'UPDATE' AND NEW.status IN ('retrying', 'failed') THEN NEW.last_error END
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER job_events_record
+AFTER INSERT OR UPDATE OF status, attempts ON jobs
+FOR EACH ROW EXECUTE FUNCTION record_job_event();
+
+-- Jobs created before the timeline existed start with their creation
+INSERT INTO job_events (job_id, event, status, attempt, actor, created_at)
+SELECT id, 'created', status, attempts, 'system', created_at FROM jobs;
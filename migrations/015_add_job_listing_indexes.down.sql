@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_jobs_user_run_at;
+DROP INDEX IF EXISTS idx_jobs_user_created_at;
@@ -0,0 +1,3 @@
+-- Keyset pagination of a user's jobs walks (sort column, id) in either direction
+CREATE INDEX idx_jobs_user_created_at ON jobs (user_id, created_at, id);
+CREATE INDEX idx_jobs_user_run_at ON jobs (user_id, run_at, id);
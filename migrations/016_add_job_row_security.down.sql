@@ -0,0 +1,13 @@
+DROP POLICY IF EXISTS job_events_owner ON job_events;
+ALTER TABLE job_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE job_events DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS dead_jobs_owner ON dead_jobs;
+ALTER TABLE dead_jobs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE dead_jobs DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS jobs_owner ON jobs;
+ALTER TABLE jobs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE jobs DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS app_can_access(UUID);
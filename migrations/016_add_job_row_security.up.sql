@@ -0,0 +1,30 @@
+-- Row level security backs the ownership checks of the application. Requests
+-- set app.user_id and app.role once authenticated, such a transaction only
+-- sees the rows of its user unless it acts for an admin. Transactions of the
+-- workers and the scheduler set no user and see every row.
+CREATE OR REPLACE FUNCTION app_can_access(owner UUID)
+RETURNS boolean AS $$
+    SELECT COALESCE(current_setting('app.user_id', true), '') = ''
+        OR current_setting('app.role', true) = 'admin'
+        OR owner::text = current_setting('app.user_id', true)
+$$ LANGUAGE sql STABLE;
+
+-- FORCE applies the policies to the table owner the application connects as;
+-- superusers still bypass them
+ALTER TABLE jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE jobs FORCE ROW LEVEL SECURITY;
+CREATE POLICY jobs_owner ON jobs
+    USING (app_can_access(user_id))
+    WITH CHECK (app_can_access(user_id));
+
+ALTER TABLE dead_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE dead_jobs FORCE ROW LEVEL SECURITY;
+CREATE POLICY dead_jobs_owner ON dead_jobs
+    USING (app_can_access(user_id))
+    WITH CHECK (app_can_access(user_id));
+
+-- Events belong to whoever can see their job
+ALTER TABLE job_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE job_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY job_events_owner ON job_events
+    USING (EXISTS (SELECT 1 FROM jobs WHERE jobs.id = job_events.job_id));
@@ -0,0 +1,3 @@
+-- Role a user's tokens are minted with, see middleware.RequirePermission
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'operator', 'admin'));
@@ -0,0 +1,21 @@
+CREATE OR REPLACE FUNCTION notify_job_update()
+RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND (NEW.progress, NEW.progress_message) IS DISTINCT FROM (OLD.progress, OLD.progress_message)
+        AND to_jsonb(NEW) - 'progress' - 'progress_message' = to_jsonb(OLD) - 'progress' - 'progress_message' THEN
+        PERFORM pg_notify('job_progress', json_build_object(
+            'event', 'progress',
+            'job_id', NEW.id,
+            'job_type', NEW.type,
+            'status', NEW.status,
+            'progress', NEW.progress,
+            'message', COALESCE(NEW.progress_message, '')
+        )::text);
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_notify('job_updates', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,7 @@
+-- The dispatch token buckets of the leader's scheduler, saved periodically so
+-- every replica reports the same state. The table holds a single row
+CREATE TABLE rate_limit_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    state JSONB NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,30 @@
+-- Postgres broker workers renew the lease of long running jobs, which changes
+-- nothing the listener reports, so lease renewals don't notify
+CREATE OR REPLACE FUNCTION notify_job_update()
+RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.locked_until IS DISTINCT FROM OLD.locked_until
+        AND to_jsonb(NEW) - 'locked_until' = to_jsonb(OLD) - 'locked_until' THEN
+        RETURN NEW;
+    END IF;
+
+    IF TG_OP = 'UPDATE'
+        AND (NEW.progress, NEW.progress_message) IS DISTINCT FROM (OLD.progress, OLD.progress_message)
+        AND to_jsonb(NEW) - 'progress' - 'progress_message' = to_jsonb(OLD) - 'progress' - 'progress_message' THEN
+        PERFORM pg_notify('job_progress', json_build_object(
+            'event', 'progress',
+            'job_id', NEW.id,
+            'job_type', NEW.type,
+            'status', NEW.status,
+            'progress', NEW.progress,
+            'message', COALESCE(NEW.progress_message, ''),
+            'user_id', NEW.user_id
+        )::text);
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_notify('job_updates', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;